@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/v0xg/demogif/internal/crawler"
+)
+
+// newAuthCmd builds the auth command, which manages saved logins
+func newAuthCmd() *cobra.Command {
+	authCmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Manage saved logins for recording authenticated demos",
+	}
+
+	var stateFile string
+	saveCmd := &cobra.Command{
+		Use:   "save <url>",
+		Short: "Log in by hand in a browser window and save the session",
+		Long: `Opens a visible browser at the URL. Log in, then press Enter in this terminal
+to save the cookies, localStorage and sessionStorage to a storage state file.
+Record with the saved session using --storage-state.
+
+The file holds live session credentials: keep it out of version control.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			url := args[0]
+
+			fmt.Printf("→ Opening %s... ", url)
+			browser, err := crawler.OpenForLogin(url, crawler.Options{Width: width, Height: height, Verbose: verbose})
+			if err != nil {
+				fmt.Println("failed")
+				return err
+			}
+			defer browser.Close()
+			fmt.Println("done")
+
+			fmt.Print("→ Log in in the browser window, then press Enter here to save the session ")
+			if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+				return fmt.Errorf("failed to read confirmation: %w", err)
+			}
+
+			state, err := browser.StorageState()
+			if err != nil {
+				return err
+			}
+			if err := state.Save(stateFile); err != nil {
+				return err
+			}
+
+			fmt.Printf("✓ Saved %d cookies and storage for %d origins to %s\n", len(state.Cookies), len(state.Origins), stateFile)
+			return nil
+		},
+	}
+	saveCmd.Flags().StringVarP(&stateFile, "output", "o", "storage-state.json", "Storage state file to write")
+
+	authCmd.AddCommand(saveCmd)
+	return authCmd
+}
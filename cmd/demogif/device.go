@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/v0xg/demogif/internal/crawler"
+)
+
+// resolveDevice combines the --device preset with the viewport flags. Flags
+// given explicitly override the preset, so --device "iPhone 15" --dpr 2
+// records an iPhone at 2x.
+func resolveDevice(cmd *cobra.Command) (crawler.Device, error) {
+	d := crawler.Device{Width: width, Height: height, DPR: dpr}
+	if device != "" {
+		preset, err := crawler.LookupDevice(device)
+		if err != nil {
+			return d, err
+		}
+		d = preset
+		if cmd.Flags().Changed("width") {
+			d.Width = width
+		}
+		if cmd.Flags().Changed("height") {
+			d.Height = height
+		}
+		if cmd.Flags().Changed("dpr") {
+			d.DPR = dpr
+		}
+	}
+	if cmd.Flags().Changed("mobile") {
+		d.Mobile = mobile
+		d.Touch = mobile
+	}
+
+	if d.Width <= 0 || d.Height <= 0 {
+		return d, fmt.Errorf("invalid viewport %dx%d", d.Width, d.Height)
+	}
+	if d.DPR <= 0 || d.DPR > 4 {
+		return d, fmt.Errorf("invalid --dpr %g: use a value between 0 and 4", d.DPR)
+	}
+	return d, nil
+}
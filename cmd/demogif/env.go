@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// expandEnv replaces $VAR and ${VAR} in a flag value with environment
+// variables, so secrets can be passed as '${TOKEN}' instead of typed into
+// the shell history. $$ stands for a literal $. Unset variables are an
+// error rather than silently empty.
+func expandEnv(flag, value string) (string, error) {
+	var missing []string
+	expanded := os.Expand(value, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%s refers to unset environment variable %s (write $$ for a literal $)", flag, strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// parseHeaders turns "Name: value" flags into a header map, expanding
+// environment variables in the values
+func parseHeaders(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	headers := make(map[string]string, len(values))
+	for _, h := range values {
+		name, value, ok := strings.Cut(h, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --header %q: use \"Name: value\"", h)
+		}
+		value, err := expandEnv("--header "+name, strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		headers[name] = value
+	}
+	return headers, nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/v0xg/demogif/internal/ai"
+	"github.com/v0xg/demogif/internal/crawler"
+	"github.com/v0xg/demogif/internal/executor"
+)
+
+// maxSetupIterations bounds the checkpoints a setup prompt may go through
+const maxSetupIterations = 10
+
+// runSetup runs the setup phase before recording: the scripted actions from
+// setupFile, then the actions the AI generates for setupPrompt. Nothing is
+// captured. Afterwards the page goes back to url and is crawled again, so
+// the recording starts from a clean state.
+func runSetup(browser *crawler.Browser, pageMap *crawler.PageMap, aiProvider ai.Provider, url, setupFile, setupPrompt string, opts executor.Options) (*crawler.PageMap, error) {
+	if setupFile != "" {
+		data, err := os.ReadFile(setupFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read setup actions: %w", err)
+		}
+		var actions []executor.Action
+		if err := json.Unmarshal(data, &actions); err != nil {
+			return nil, fmt.Errorf("failed to parse setup actions %s: %w", setupFile, err)
+		}
+
+		fmt.Printf("→ Running %d setup actions (not recorded)...\n", len(actions))
+		for len(actions) > 0 {
+			result, err := executor.ExecuteSetup(browser, actions, opts)
+			if err != nil {
+				return nil, err
+			}
+			if !result.HitCheckpoint {
+				break
+			}
+			actions = actions[result.CheckpointIndex+1:]
+			// Let the page settle the way a re-crawl would
+			if pageMap, err = browser.ReCrawl(); err != nil {
+				return nil, fmt.Errorf("re-crawl failed: %w", err)
+			}
+		}
+	}
+
+	if setupPrompt != "" {
+		fmt.Printf("→ Generating setup actions... ")
+		actions, err := aiProvider.GenerateActions(pageMap, setupPrompt)
+		if err != nil {
+			fmt.Println("failed")
+			flushVerbose()
+			return nil, fmt.Errorf("setup generation failed: %w", err)
+		}
+		fmt.Printf("done (%d actions)\n", len(actions))
+		flushVerbose()
+		logActions(actions)
+
+		fmt.Println("→ Running setup (not recorded)...")
+		var completed []executor.Action
+		for i := 0; len(actions) > 0 && i < maxSetupIterations; i++ {
+			result, err := executor.ExecuteSetup(browser, actions, opts)
+			if err != nil {
+				return nil, err
+			}
+			if !result.HitCheckpoint {
+				break
+			}
+			completed = append(completed, actions[:result.CheckpointIndex+1]...)
+
+			previous := pageMap
+			if pageMap, err = browser.ReCrawl(); err != nil {
+				return nil, fmt.Errorf("re-crawl failed: %w", err)
+			}
+			actions, err = aiProvider.ContinueActions(pageMap, setupPrompt, formatCompletedActions(completed), crawler.Diff(previous, pageMap))
+			flushVerbose()
+			if err != nil {
+				return nil, fmt.Errorf("setup generation failed: %w", err)
+			}
+			logActions(actions)
+		}
+	}
+
+	// Start the recording from the target page
+	fmt.Printf("→ Setup done, returning to %s... ", url)
+	page := browser.Page()
+	if err := page.Navigate(url); err != nil {
+		fmt.Println("failed")
+		return nil, fmt.Errorf("navigation failed: %w", err)
+	}
+	pageMap, err := browser.ReCrawl()
+	if err != nil {
+		fmt.Println("failed")
+		return nil, fmt.Errorf("re-crawl failed: %w", err)
+	}
+	fmt.Printf("done (found %d interactive elements)\n", len(pageMap.Elements))
+
+	return pageMap, nil
+}
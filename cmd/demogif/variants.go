@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/v0xg/demogif/internal/crawler"
+)
+
+// variant is one combination of a --variants matrix
+type variant struct {
+	ColorScheme string
+	Locale      string
+	Timezone    string
+}
+
+var (
+	// variantDimensionSep separates the dimensions of a matrix: "dark,light x en,de"
+	variantDimensionSep = regexp.MustCompile(`\s+[xX]\s+|\s*×\s*`)
+	localePattern       = regexp.MustCompile(`^[A-Za-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+)
+
+// parseVariants expands a matrix such as "dark,light x en-US,de-DE" into
+// every combination. Values are told apart by their shape: light and dark
+// are color schemes, IANA names like Europe/Berlin (and UTC) are timezones
+// and anything else is a locale.
+func parseVariants(spec string) ([]variant, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+
+	matrix := []variant{{}}
+	seen := map[string]bool{}
+	for _, dimension := range variantDimensionSep.Split(strings.TrimSpace(spec), -1) {
+		var kind string
+		var values []string
+		for _, value := range strings.Split(dimension, ",") {
+			value = strings.TrimSpace(value)
+			k := variantKind(value)
+			if k == "" {
+				return nil, fmt.Errorf("invalid --variants value %q: use light/dark, a locale like de-DE or a timezone like Europe/Berlin", value)
+			}
+			if kind != "" && k != kind {
+				return nil, fmt.Errorf("invalid --variants dimension %q: mixes %ss and %ss", dimension, kind, k)
+			}
+			kind = k
+			values = append(values, value)
+		}
+
+		var expanded []variant
+		for _, v := range matrix {
+			for _, value := range values {
+				switch kind {
+				case "color scheme":
+					v.ColorScheme = strings.ToLower(value)
+				case "locale":
+					v.Locale = value
+				case "timezone":
+					v.Timezone = value
+				}
+				expanded = append(expanded, v)
+			}
+		}
+		if seen[kind] {
+			return nil, fmt.Errorf("invalid --variants: more than one dimension of %ss", kind)
+		}
+		seen[kind] = true
+		matrix = expanded
+	}
+	return matrix, nil
+}
+
+// variantKind classifies one value of a matrix dimension
+func variantKind(value string) string {
+	switch {
+	case strings.EqualFold(value, crawler.ColorSchemeLight), strings.EqualFold(value, crawler.ColorSchemeDark):
+		return "color scheme"
+	case strings.Contains(value, "/"), value == "UTC":
+		return "timezone"
+	case localePattern.MatchString(value):
+		return "locale"
+	}
+	return ""
+}
+
+// apply overrides the options with the values of the variant
+func (v variant) apply(opts crawler.Options) crawler.Options {
+	if v.ColorScheme != "" {
+		opts.ColorScheme = v.ColorScheme
+	}
+	if v.Locale != "" {
+		opts.Locale = v.Locale
+	}
+	if v.Timezone != "" {
+		opts.Timezone = v.Timezone
+	}
+	return opts
+}
+
+// parts lists the values of the variant in matrix order
+func (v variant) parts() []string {
+	var parts []string
+	for _, p := range []string{v.ColorScheme, v.Locale, v.Timezone} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+func (v variant) String() string {
+	return strings.Join(v.parts(), ", ")
+}
+
+// output names the GIF of the variant after the base output, e.g.
+// demo.gif becomes demo-dark-de-DE.gif
+func (v variant) output(base string) string {
+	ext := filepath.Ext(base)
+	suffix := strings.ReplaceAll(strings.Join(v.parts(), "-"), "/", "-")
+	return strings.TrimSuffix(base, ext) + "-" + suffix + ext
+}
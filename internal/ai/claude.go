@@ -17,10 +17,11 @@ import (
 type ClaudeProvider struct {
 	client *anthropic.Client
 	model  string
+	opts   Options
 }
 
 // NewClaudeProvider creates a new Claude provider
-func NewClaudeProvider(model string) (*ClaudeProvider, error) {
+func NewClaudeProvider(model string, opts Options) (*ClaudeProvider, error) {
 	apiKey := os.Getenv("DEMOGIF_ANTHROPIC_KEY")
 	if apiKey == "" {
 		apiKey = os.Getenv("ANTHROPIC_API_KEY")
@@ -38,21 +39,22 @@ func NewClaudeProvider(model string) (*ClaudeProvider, error) {
 	return &ClaudeProvider{
 		client: &client,
 		model:  model,
+		opts:   opts,
 	}, nil
 }
 
 // GenerateActions generates browser actions from the page map and user prompt
 func (p *ClaudeProvider) GenerateActions(pageMap *crawler.PageMap, prompt string) ([]executor.Action, error) {
-	pageMapJSON, err := json.MarshalIndent(pageMap, "", "  ")
+	compact, err := compactPageMap(pageMap, prompt, p.opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal page map: %w", err)
+		return nil, err
 	}
 
-	userPrompt := buildUserPrompt(string(pageMapJSON), prompt)
+	userPrompt := buildUserPrompt(compact, prompt)
 
 	resp, err := p.client.Messages.New(context.Background(), anthropic.MessageNewParams{
 		Model:     anthropic.Model(p.model),
-		MaxTokens: 1024,
+		MaxTokens: maxResponseTokens,
 		System: []anthropic.TextBlockParam{
 			{Text: systemPrompt},
 		},
@@ -87,17 +89,17 @@ func (p *ClaudeProvider) GenerateActions(pageMap *crawler.PageMap, prompt string
 }
 
 // ContinueActions generates the next batch of actions after a checkpoint
-func (p *ClaudeProvider) ContinueActions(pageMap *crawler.PageMap, originalPrompt string, completedActions string) ([]executor.Action, error) {
-	pageMapJSON, err := json.MarshalIndent(pageMap, "", "  ")
+func (p *ClaudeProvider) ContinueActions(pageMap *crawler.PageMap, originalPrompt string, completedActions string, diff *crawler.PageDiff) ([]executor.Action, error) {
+	compact, err := compactPageMap(pageMap, originalPrompt, p.opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal page map: %w", err)
+		return nil, err
 	}
 
-	userPrompt := buildContinuePrompt(string(pageMapJSON), originalPrompt, completedActions)
+	userPrompt := buildContinuePrompt(compact, originalPrompt, completedActions, diff)
 
 	resp, err := p.client.Messages.New(context.Background(), anthropic.MessageNewParams{
 		Model:     anthropic.Model(p.model),
-		MaxTokens: 1024,
+		MaxTokens: maxResponseTokens,
 		System: []anthropic.TextBlockParam{
 			{Text: systemPrompt},
 		},
@@ -0,0 +1,240 @@
+package ai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/v0xg/demogif/internal/crawler"
+)
+
+// DefaultTokenBudget is roughly how many tokens the page map may take up in
+// a prompt when Options.TokenBudget isn't set
+const DefaultTokenBudget = 6000
+
+// maxSimilarRows is how many elements of a repeated list row are kept
+const maxSimilarRows = 3
+
+// maxNavItems caps the navigation links sent to the model
+const maxNavItems = 40
+
+// compactElement is an element as sent to the model. Similar counts the
+// collapsed elements that look just like it (e.g. the same button in other
+// rows of a table).
+type compactElement struct {
+	crawler.Element
+	Similar int `json:"similar,omitempty"`
+}
+
+// digits matches the varying parts of selectors of repeated rows
+var digits = regexp.MustCompile(`[0-9]+`)
+
+// promptWords splits a prompt into lowercase words worth matching
+var promptWords = regexp.MustCompile(`[\p{L}\p{N}]{3,}`)
+
+// stopWords are prompt words too common to say anything about relevance
+var stopWords = map[string]bool{
+	"the": true, "and": true, "then": true, "with": true, "for": true,
+	"into": true, "from": true, "that": true, "this": true, "click": true,
+	"type": true, "fill": true, "page": true, "button": true, "field": true,
+}
+
+// estimateTokens approximates the token count of serialized text
+func estimateTokens(s string) int {
+	return len(s)/4 + 1
+}
+
+// compactPageMap serializes the page map for a prompt: one compact JSON
+// object per line, repeated list rows collapsed, and the elements least
+// relevant to the prompt dropped until it fits the token budget
+func compactPageMap(pageMap *crawler.PageMap, prompt string, opts Options) (string, error) {
+	budget := opts.TokenBudget
+	if budget <= 0 {
+		budget = DefaultTokenBudget
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "URL: %s\nTitle: %s\nSPA: %t\n", pageMap.URL, pageMap.Title, pageMap.IsSPA)
+
+	nav := pageMap.Navigation
+	if len(nav) > maxNavItems {
+		nav = nav[:maxNavItems]
+	}
+	if len(nav) > 0 {
+		b.WriteString("\nNavigation:\n")
+		for _, item := range nav {
+			line, err := marshalLine(item)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(line)
+		}
+	}
+
+	elements, collapsed := collapseRepeatedRows(pageMap.Elements)
+
+	// Serialize every candidate once to know what it costs
+	lines := make([]string, len(elements))
+	for i, el := range elements {
+		line, err := marshalLine(el)
+		if err != nil {
+			return "", err
+		}
+		lines[i] = line
+	}
+
+	// Keep the most relevant elements that fit, in page order
+	remaining := budget - estimateTokens(b.String()) - 20
+	keep := make([]bool, len(elements))
+	words := relevantWords(prompt)
+	order := make([]int, len(elements))
+	for i := range order {
+		order[i] = i
+	}
+	scores := make([]int, len(elements))
+	for i, el := range elements {
+		scores[i] = relevance(el.Element, words)
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	kept := 0
+	for _, i := range order {
+		cost := estimateTokens(lines[i])
+		if cost > remaining {
+			continue
+		}
+		remaining -= cost
+		keep[i] = true
+		kept++
+	}
+
+	fmt.Fprintf(&b, "\nElements (%d of %d", kept, len(pageMap.Elements))
+	if collapsed > 0 {
+		b.WriteString(`; "similar" counts hidden look-alikes in repeated rows`)
+	}
+	b.WriteString("):\n")
+	var dropped []crawler.Element
+	for i, line := range lines {
+		if keep[i] {
+			b.WriteString(line)
+		} else {
+			dropped = append(dropped, elements[i].Element)
+		}
+	}
+
+	if log := opts.Log; log != nil {
+		log("  Page map: kept %d of %d elements (~%d tokens, budget %d)",
+			kept, len(pageMap.Elements), estimateTokens(b.String()), budget)
+		if collapsed > 0 {
+			log("    collapsed %d repeated list rows", collapsed)
+		}
+		if len(pageMap.Navigation) > len(nav) {
+			log("    dropped %d navigation links", len(pageMap.Navigation)-len(nav))
+		}
+		if len(dropped) > 0 {
+			log("    dropped %d least relevant elements:", len(dropped))
+			for i, el := range dropped {
+				if i == 10 {
+					log("      … and %d more", len(dropped)-10)
+					break
+				}
+				log("      %s %s", el.Type, describeElement(el))
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// marshalLine encodes v as one line of JSON, leaving characters like > in
+// selectors unescaped so the model can copy them verbatim
+func marshalLine(v any) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return "", fmt.Errorf("failed to marshal page map: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// collapseRepeatedRows keeps the first few elements of each group that
+// differs only by position (same type, text and context, and a selector
+// that only differs in numbers) and counts the rest on the last one kept
+func collapseRepeatedRows(elements []crawler.Element) ([]compactElement, int) {
+	type group struct {
+		last  int // Index in the result of the last element kept
+		count int
+	}
+	groups := map[string]*group{}
+	var result []compactElement
+	collapsed := 0
+	for _, el := range elements {
+		key := strings.Join([]string{el.Type, el.Text, el.Label, el.Context, strings.Join(el.Frame, ","),
+			digits.ReplaceAllString(el.Selector, "#")}, "\x00")
+		g := groups[key]
+		if g == nil {
+			g = &group{}
+			groups[key] = g
+		}
+		g.count++
+		if g.count > maxSimilarRows {
+			result[g.last].Similar++
+			collapsed++
+			continue
+		}
+		result = append(result, compactElement{Element: el})
+		g.last = len(result) - 1
+	}
+	return result, collapsed
+}
+
+// relevantWords picks the words of the prompt to rank elements by
+func relevantWords(prompt string) []string {
+	var words []string
+	for _, w := range promptWords.FindAllString(strings.ToLower(prompt), -1) {
+		if !stopWords[w] {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// relevance scores an element by how much of the prompt its descriptions
+// mention, preferring elements the user can see and use
+func relevance(el crawler.Element, words []string) int {
+	texts := []string{el.Text, el.Label, el.Placeholder, el.Name, el.ID, el.AccessibleName, el.Context}
+	if el.Locator != nil {
+		texts = append(texts, el.Locator.TestID, el.Locator.Name)
+	}
+	for _, o := range el.Options {
+		texts = append(texts, o.Label)
+	}
+	haystack := strings.ToLower(strings.Join(texts, " "))
+
+	score := 0
+	for _, w := range words {
+		if strings.Contains(haystack, w) {
+			score += 10
+		}
+	}
+	if el.InViewport {
+		score += 2
+	}
+	if el.Disabled {
+		score--
+	}
+	return score
+}
+
+// describeElement names an element for verbose output
+func describeElement(el crawler.Element) string {
+	for _, s := range []string{el.Text, el.Label, el.AccessibleName, el.Placeholder} {
+		if s != "" {
+			return fmt.Sprintf("%q", s)
+		}
+	}
+	return el.Selector
+}
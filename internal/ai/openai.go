@@ -2,7 +2,6 @@ package ai
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 
@@ -15,10 +14,11 @@ import (
 type OpenAIProvider struct {
 	client *openai.Client
 	model  string
+	opts   Options
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
-func NewOpenAIProvider(model string) (*OpenAIProvider, error) {
+func NewOpenAIProvider(model string, opts Options) (*OpenAIProvider, error) {
 	apiKey := os.Getenv("DEMOGIF_OPENAI_KEY")
 	if apiKey == "" {
 		apiKey = os.Getenv("OPENAI_API_KEY")
@@ -36,17 +36,18 @@ func NewOpenAIProvider(model string) (*OpenAIProvider, error) {
 	return &OpenAIProvider{
 		client: client,
 		model:  model,
+		opts:   opts,
 	}, nil
 }
 
 // GenerateActions generates browser actions from the page map and user prompt
 func (p *OpenAIProvider) GenerateActions(pageMap *crawler.PageMap, prompt string) ([]executor.Action, error) {
-	pageMapJSON, err := json.MarshalIndent(pageMap, "", "  ")
+	compact, err := compactPageMap(pageMap, prompt, p.opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal page map: %w", err)
+		return nil, err
 	}
 
-	userPrompt := buildUserPrompt(string(pageMapJSON), prompt)
+	userPrompt := buildUserPrompt(compact, prompt)
 
 	resp, err := p.client.CreateChatCompletion(
 		context.Background(),
@@ -62,7 +63,7 @@ func (p *OpenAIProvider) GenerateActions(pageMap *crawler.PageMap, prompt string
 					Content: userPrompt,
 				},
 			},
-			MaxTokens: 1024,
+			MaxTokens: maxResponseTokens,
 		},
 	)
 	if err != nil {
@@ -85,13 +86,13 @@ func (p *OpenAIProvider) GenerateActions(pageMap *crawler.PageMap, prompt string
 }
 
 // ContinueActions generates the next batch of actions after a checkpoint
-func (p *OpenAIProvider) ContinueActions(pageMap *crawler.PageMap, originalPrompt string, completedActions string) ([]executor.Action, error) {
-	pageMapJSON, err := json.MarshalIndent(pageMap, "", "  ")
+func (p *OpenAIProvider) ContinueActions(pageMap *crawler.PageMap, originalPrompt string, completedActions string, diff *crawler.PageDiff) ([]executor.Action, error) {
+	compact, err := compactPageMap(pageMap, originalPrompt, p.opts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal page map: %w", err)
+		return nil, err
 	}
 
-	userPrompt := buildContinuePrompt(string(pageMapJSON), originalPrompt, completedActions)
+	userPrompt := buildContinuePrompt(compact, originalPrompt, completedActions, diff)
 
 	resp, err := p.client.CreateChatCompletion(
 		context.Background(),
@@ -107,7 +108,7 @@ func (p *OpenAIProvider) ContinueActions(pageMap *crawler.PageMap, originalPromp
 					Content: userPrompt,
 				},
 			},
-			MaxTokens: 1024,
+			MaxTokens: maxResponseTokens,
 		},
 	)
 	if err != nil {
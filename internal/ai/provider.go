@@ -10,16 +10,25 @@ import (
 // Provider defines the interface for AI action generation
 type Provider interface {
 	GenerateActions(pageMap *crawler.PageMap, prompt string) ([]executor.Action, error)
-	ContinueActions(pageMap *crawler.PageMap, originalPrompt string, completedActions string) ([]executor.Action, error)
+	ContinueActions(pageMap *crawler.PageMap, originalPrompt string, completedActions string, diff *crawler.PageDiff) ([]executor.Action, error)
+}
+
+// maxResponseTokens bounds the length of a generated action list
+const maxResponseTokens = 4096
+
+// Options configures AI providers
+type Options struct {
+	TokenBudget int                              // Approximate token budget for the page map (0 = DefaultTokenBudget)
+	Log         func(format string, args ...any) // Receives one line per call on what page map compaction dropped (nil: not reported)
 }
 
 // NewProvider creates a new AI provider based on the provider name
-func NewProvider(name, model string) (Provider, error) {
+func NewProvider(name, model string, opts Options) (Provider, error) {
 	switch name {
 	case "claude", "anthropic":
-		return NewClaudeProvider(model)
+		return NewClaudeProvider(model, opts)
 	case "openai", "gpt":
-		return NewOpenAIProvider(model)
+		return NewOpenAIProvider(model, opts)
 	default:
 		return nil, fmt.Errorf("unknown provider: %s (supported: claude, openai)", name)
 	}
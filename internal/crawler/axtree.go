@@ -0,0 +1,270 @@
+package crawler
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Element discovery modes
+const (
+	DiscoveryDOM = "dom" // Query the DOM for interactive elements (default)
+	DiscoveryAX  = "ax"  // Walk the accessibility tree for roles and accessible names
+)
+
+// axRoles are the accessibility roles reported in accessibility discovery
+// mode, mapped to the element type shown in the page map
+var axRoles = map[string]string{
+	"button":           "button",
+	"link":             "link",
+	"textbox":          "text",
+	"searchbox":        "search",
+	"combobox":         "combobox",
+	"listbox":          "listbox",
+	"checkbox":         "checkbox",
+	"radio":            "radio",
+	"switch":           "switch",
+	"slider":           "slider",
+	"spinbutton":       "number",
+	"tab":              "tab",
+	"menuitem":         "menuitem",
+	"menuitemcheckbox": "menuitemcheckbox",
+	"menuitemradio":    "menuitemradio",
+	"option":           "option",
+	"treeitem":         "treeitem",
+}
+
+// roleLocatorPattern matches locators like role=button[name="Close"][nth=1]
+var roleLocatorPattern = regexp.MustCompile(`^role=([a-z]+)(?:\[name=("(?:[^"\\]|\\.)*")\])?(?:\[nth=(\d+)\])?$`)
+
+// roleLookupTimeout bounds how long a role locator waits for its element
+const roleLookupTimeout = 10 * time.Second
+
+// RoleLocator builds a locator that finds an element by its accessibility
+// role and accessible name. nth picks among elements sharing both (0-based).
+func RoleLocator(role, name string, nth int) string {
+	locator := "role=" + role
+	if name != "" {
+		locator += "[name=" + strconv.Quote(name) + "]"
+	}
+	if nth > 0 {
+		locator += fmt.Sprintf("[nth=%d]", nth)
+	}
+	return locator
+}
+
+// parseRoleLocator splits a locator built by RoleLocator into its parts
+func parseRoleLocator(locator string) (role, name string, nth int, err error) {
+	m := roleLocatorPattern.FindStringSubmatch(locator)
+	if m == nil {
+		return "", "", 0, fmt.Errorf("invalid role locator: %s", locator)
+	}
+	role = m[1]
+	if m[2] != "" {
+		if name, err = strconv.Unquote(m[2]); err != nil {
+			return "", "", 0, fmt.Errorf("invalid role locator: %s", locator)
+		}
+	}
+	if m[3] != "" {
+		nth, _ = strconv.Atoi(m[3])
+	}
+	return role, name, nth, nil
+}
+
+// extractAXElements finds interactive elements through the accessibility
+// tree, which knows the computed role and accessible name of each node
+func extractAXElements(page *rod.Page) ([]Element, error) {
+	if err := (proto.AccessibilityEnable{}).Call(page); err != nil {
+		return nil, err
+	}
+	tree, err := proto.AccessibilityGetFullAXTree{}.Call(page)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without layout metrics elements just aren't marked as on screen
+	metrics, _ := proto.PageGetLayoutMetrics{}.Call(page)
+
+	var elements []Element
+	seen := map[string]int{}
+	for _, node := range tree.Nodes {
+		if node.Ignored || node.Role == nil || node.BackendDOMNodeID == 0 {
+			continue
+		}
+		role := node.Role.Value.String()
+		elementType, ok := axRoles[role]
+		if !ok {
+			continue
+		}
+
+		name := axNodeName(node)
+		el := Element{
+			Type:           elementType,
+			Role:           role,
+			AccessibleName: truncate(name, 80),
+		}
+		hidden := false
+		for _, prop := range node.Properties {
+			if prop.Value == nil {
+				continue
+			}
+			switch prop.Name {
+			case proto.AccessibilityAXPropertyNameHidden:
+				hidden = prop.Value.Value.Bool()
+			case proto.AccessibilityAXPropertyNameDisabled:
+				el.Disabled = prop.Value.Value.Bool()
+			case proto.AccessibilityAXPropertyNameExpanded:
+				expanded := prop.Value.Value.Bool()
+				el.Expanded = &expanded
+			case proto.AccessibilityAXPropertyNameChecked:
+				el.Checked = prop.Value.Value.String()
+			case proto.AccessibilityAXPropertyNameReadonly:
+				el.Readonly = prop.Value.Value.Bool()
+			case proto.AccessibilityAXPropertyNameRequired:
+				el.Required = prop.Value.Value.Bool()
+			}
+		}
+		if hidden {
+			continue
+		}
+		if node.Value != nil && (role == "textbox" || role == "searchbox" || role == "combobox") {
+			el.Text = truncate(node.Value.Value.String(), 50)
+		}
+
+		if box := axNodeBox(page, node.BackendDOMNodeID); box != nil {
+			el.Box = box
+			if metrics != nil && metrics.CSSLayoutViewport != nil {
+				vp := metrics.CSSLayoutViewport
+				el.InViewport = box.X+box.Width > 0 && box.Y+box.Height > 0 &&
+					box.X < vp.ClientWidth && box.Y < vp.ClientHeight
+			}
+		}
+
+		// Elements sharing a role and name are told apart by position
+		key := role + "\x00" + name
+		el.Selector = RoleLocator(role, name, seen[key])
+		seen[key]++
+
+		elements = append(elements, el)
+	}
+
+	return elements, nil
+}
+
+// axNodeBox returns the viewport bounding box of a DOM node, or nil if it
+// isn't rendered
+func axNodeBox(page *rod.Page, id proto.DOMBackendNodeID) *Rect {
+	res, err := proto.DOMGetContentQuads{BackendNodeID: id}.Call(page)
+	if err != nil || len(res.Quads) == 0 || res.Quads[0].Len() == 0 {
+		return nil
+	}
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	res.Quads[0].Each(func(pt proto.Point, _ int) {
+		minX, minY = math.Min(minX, pt.X), math.Min(minY, pt.Y)
+		maxX, maxY = math.Max(maxX, pt.X), math.Max(maxY, pt.Y)
+	})
+	return &Rect{
+		X:      int(math.Round(minX)),
+		Y:      int(math.Round(minY)),
+		Width:  int(math.Round(maxX - minX)),
+		Height: int(math.Round(maxY - minY)),
+	}
+}
+
+// findByRole resolves a role locator by querying the accessibility tree of
+// the page (or frame) document, retrying while the page renders
+func findByRole(page *rod.Page, locator string) (*rod.Element, error) {
+	role, name, nth, err := parseRoleLocator(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(roleLookupTimeout)
+	for {
+		el, err := queryRole(page, role, name, nth)
+		if el != nil {
+			return el, nil
+		}
+		if err == nil {
+			err = fmt.Errorf("no element with %s", RoleLocator(role, name, nth))
+		}
+		if time.Now().After(deadline) {
+			return nil, err
+		}
+		letTimePass(page, 200*time.Millisecond)
+	}
+}
+
+// QueryRole resolves a role locator once, without waiting for the element.
+// It returns nil when no element matches.
+func QueryRole(page *rod.Page, locator string) (*rod.Element, error) {
+	role, name, nth, err := parseRoleLocator(locator)
+	if err != nil {
+		return nil, err
+	}
+	return queryRole(page, role, name, nth)
+}
+
+// queryRole finds the nth element with the role and accessible name, counted
+// the way extractAXElements numbers them, or nil if there is none
+func queryRole(page *rod.Page, role, name string, nth int) (*rod.Element, error) {
+	doc, err := page.Evaluate(rod.Eval(`() => document`).ByObject())
+	if err != nil {
+		return nil, err
+	}
+	// The name is matched here rather than by the query, which would drop
+	// an empty one and doesn't trim
+	res, err := proto.AccessibilityQueryAXTree{
+		ObjectID: doc.ObjectID,
+		Role:     role,
+	}.Call(page)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*proto.AccessibilityAXNode
+	for _, node := range res.Nodes {
+		if !node.Ignored && node.BackendDOMNodeID != 0 && !axNodeHidden(node) && axNodeName(node) == name {
+			matches = append(matches, node)
+		}
+	}
+	if nth >= len(matches) {
+		return nil, nil
+	}
+
+	return page.ElementFromNode(&proto.DOMNode{BackendNodeID: matches[nth].BackendDOMNodeID})
+}
+
+// axNodeName returns the accessible name of a node
+func axNodeName(node *proto.AccessibilityAXNode) string {
+	if node.Name == nil {
+		return ""
+	}
+	return strings.TrimSpace(node.Name.Value.String())
+}
+
+// axNodeHidden reports whether a node is hidden from the accessibility tree
+func axNodeHidden(node *proto.AccessibilityAXNode) bool {
+	for _, prop := range node.Properties {
+		if prop.Name == proto.AccessibilityAXPropertyNameHidden && prop.Value != nil {
+			return prop.Value.Value.Bool()
+		}
+	}
+	return false
+}
+
+// truncate shortens s to at most n runes
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,188 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// DefaultBlockedURLs are the scripts of chat widgets, consent managers and
+// trackers that are blocked with Options.Blocklist. Patterns use * wildcards.
+var DefaultBlockedURLs = []string{
+	// Chat widgets
+	"*://widget.intercom.io/*",
+	"*://js.intercomcdn.com/*",
+	"*://js.driftt.com/*",
+	"*://js.drift.com/*",
+	"*://client.crisp.chat/*",
+	"*://static.zdassets.com/*",
+	"*://js.usemessages.com/*",
+	"*://embed.tawk.to/*",
+	"*://code.tidio.co/*",
+	"*://wchat.freshchat.com/*",
+	// Consent managers
+	"*://cdn.cookielaw.org/*",
+	"*://*.onetrust.com/*",
+	"*://consent.cookiebot.com/*",
+	"*://consentcdn.cookiebot.com/*",
+	"*://app.usercentrics.eu/*",
+	"*://*.usercentrics.eu/*",
+	"*://quantcast.mgr.consensu.org/*",
+	"*://cmp.quantcast.com/*",
+	"*://consent.trustarc.com/*",
+	"*://sdk.privacy-center.org/*",
+	"*://cdn.iubenda.com/*",
+	"*://*.termly.io/*",
+	// Trackers and session recorders
+	"*://www.googletagmanager.com/*",
+	"*://www.google-analytics.com/*",
+	"*://*.doubleclick.net/*",
+	"*://connect.facebook.net/*",
+	"*://cdn.segment.com/*",
+	"*://static.hotjar.com/*",
+	"*://script.hotjar.com/*",
+	"*://edge.fullstory.com/*",
+	"*://cdn.mxpnl.com/*",
+	"*://cdn.amplitude.com/*",
+	"*://js.hs-scripts.com/*",
+	"*://snap.licdn.com/*",
+}
+
+// DefaultHiddenSelectors hide widgets and banners that are served from the
+// site itself, where blocking their URL isn't possible
+var DefaultHiddenSelectors = []string{
+	"#intercom-container",
+	".intercom-lightweight-app",
+	".intercom-launcher",
+	"#drift-widget-container",
+	"#drift-frame-controller",
+	"#drift-frame-chat",
+	".crisp-client",
+	"#hubspot-messages-iframe-container",
+	".tawk-min-container",
+	"#tidio-chat",
+	"#fc_frame",
+	"#onetrust-consent-sdk",
+	"#CybotCookiebotDialog",
+	"#usercentrics-root",
+	".qc-cmp2-container",
+	"#truste-consent-track",
+	"#didomi-host",
+	".iubenda-cs-container",
+	"#termly-code-snippet-support",
+	".cc-window.cc-banner",
+	"#cookie-law-info-bar",
+	"#cookie-notice",
+	"#cmplz-cookiebanner-container",
+}
+
+// hideCSS builds the rules that hide the selectors. Each selector gets its
+// own rule, so one the browser can't parse doesn't void the others.
+func hideCSS(selectors []string) string {
+	var b strings.Builder
+	for _, s := range selectors {
+		fmt.Fprintf(&b, "%s { display: none !important; }\n", s)
+	}
+	return b.String()
+}
+
+// blockedURLs returns the URL patterns to block for the options
+func (o Options) blockedURLs() []string {
+	var urls []string
+	if o.Blocklist {
+		urls = append(urls, DefaultBlockedURLs...)
+	}
+	return append(urls, o.BlockURLs...)
+}
+
+// hiddenSelectors returns the selectors to hide for the options
+func (o Options) hiddenSelectors() []string {
+	var selectors []string
+	if o.Blocklist {
+		selectors = append(selectors, DefaultHiddenSelectors...)
+	}
+	return append(selectors, o.HideSelectors...)
+}
+
+// applyHiding hides the selectors of the options in every document the page
+// loads. Blocked URLs are failed by the request interception of
+// applyNetworkOptions.
+func applyHiding(page *rod.Page, opts Options) error {
+	if selectors := opts.hiddenSelectors(); len(selectors) > 0 {
+		return injectStyle(page, hideCSS(selectors))
+	}
+	return nil
+}
+
+// dismissConsentJS clicks the "accept" button of a cookie consent dialog,
+// first those of known consent managers, then any button with accepting
+// text inside something that looks like a consent banner. It returns the
+// text of the button it clicked, or an empty string.
+const dismissConsentJS = `() => {` + DeepQueryJS + `
+	const visible = el => el && el.offsetParent !== null;
+	const known = [
+		'#onetrust-accept-btn-handler',
+		'#CybotCookiebotDialogBodyLevelButtonLevelOptinAllowAll',
+		'#CybotCookiebotDialogBodyButtonAccept',
+		'[data-testid="uc-accept-all-button"]',
+		'.qc-cmp2-summary-buttons button[mode="primary"]',
+		'#truste-consent-button',
+		'#didomi-notice-agree-button',
+		'.iubenda-cs-accept-btn',
+		'.cc-window .cc-allow, .cc-window .cc-dismiss',
+		'#cookie_action_close_header',
+		'.cmplz-accept'
+	];
+	// Known buttons are clicked even when hidden by the blocklist's rules,
+	// so the consent manager also lifts its scroll lock
+	for (const selector of known) {
+		const button = deepQueryAll(selector)[0];
+		if (button) {
+			button.click();
+			return (button.innerText || selector).trim();
+		}
+	}
+
+	const banner = /cookie|consent|gdpr|privacy|cmp/i;
+	const accept = /^(accept|agree|allow|ok|okay|got it|i understand|akzeptieren|alle akzeptieren|zustimmen|einverstanden|accepter|tout accepter|j'accepte|aceptar|aceitar|accetta|accetto|akkoord|godkänn|zaakceptuj)/i;
+	const inBanner = el => {
+		for (let node = el; node; node = node.parentElement || (node.getRootNode() && node.getRootNode().host)) {
+			const label = [node.id, typeof node.className === 'string' ? node.className : '', node.getAttribute && node.getAttribute('aria-label')].join(' ');
+			if (banner.test(label)) return true;
+		}
+		return false;
+	};
+	const buttons = deepQueryAll('button, [role="button"], a[href="#"], input[type="button"], input[type="submit"]');
+	for (const button of buttons) {
+		const text = (button.innerText || button.value || '').trim();
+		if (visible(button) && accept.test(text) && inBanner(button)) {
+			button.click();
+			return text;
+		}
+	}
+	return '';
+}`
+
+// DismissConsent accepts a cookie consent dialog if one shows up within
+// timeout, so it isn't in the way of the recording. It returns the label of
+// the button it clicked, or an empty string when there was no dialog.
+func (b *Browser) DismissConsent(timeout time.Duration) (string, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		res, err := b.page.Eval(dismissConsentJS)
+		if err != nil {
+			return "", fmt.Errorf("failed to dismiss consent dialog: %w", err)
+		}
+		if clicked := res.Value.String(); clicked != "" {
+			// Let the dialog close and the page settle
+			b.page.Timeout(3*time.Second).WaitRequestIdle(300*time.Millisecond, nil, nil, nil)()
+			return clicked, nil
+		}
+		if time.Now().After(deadline) {
+			return "", nil
+		}
+		letTimePass(b.page, 250*time.Millisecond)
+	}
+}
@@ -0,0 +1,97 @@
+package crawler
+
+// elementDetailsJS defines detailsOf(el), which describes where an element
+// is, what state it is in and what it belongs to. It is meant to be placed
+// after DeepQueryJS in a function body passed to Eval.
+const elementDetailsJS = `
+	const ariaStates = ['pressed', 'selected', 'current', 'invalid', 'haspopup'];
+	const landmarkTags = { DIALOG: 'dialog', NAV: 'navigation', MAIN: 'main', ASIDE: 'complementary', FORM: 'form' };
+	const landmarkRoles = ['dialog', 'alertdialog', 'navigation', 'main', 'banner', 'contentinfo',
+		'complementary', 'form', 'search', 'region', 'menu', 'tabpanel'];
+	let headings = null;
+
+	function clean(s) {
+		return (s || '').replace(/\s+/g, ' ').trim();
+	}
+	// Parent element, stepping out of shadow roots to their host
+	function parentOf(el) {
+		if (el.parentElement) return el.parentElement;
+		const root = el.getRootNode();
+		return root instanceof ShadowRoot ? root.host : null;
+	}
+	function textOfIds(el, ids) {
+		const root = el.getRootNode();
+		return clean((ids || '').split(/\s+/)
+			.map(id => id && root.getElementById(id))
+			.filter(Boolean)
+			.map(n => n.textContent)
+			.join(' '));
+	}
+	function labelOf(el) {
+		if (el.labels && el.labels.length > 0) {
+			const text = clean(Array.from(el.labels).map(l => l.textContent).join(' '));
+			if (text) return text;
+		}
+		return textOfIds(el, el.getAttribute('aria-labelledby')) || clean(el.getAttribute('aria-label'));
+	}
+	function landmarkOf(el) {
+		for (let n = parentOf(el); n; n = parentOf(n)) {
+			const role = n.getAttribute('role');
+			let kind = landmarkRoles.includes(role) ? role : landmarkTags[n.tagName];
+			if (!kind && n.tagName === 'HEADER' && !n.closest('article, aside, main, nav, section')) kind = 'banner';
+			if (!kind && n.tagName === 'FOOTER' && !n.closest('article, aside, main, nav, section')) kind = 'contentinfo';
+			if (!kind && n.tagName === 'SECTION' && (n.getAttribute('aria-label') || n.getAttribute('aria-labelledby'))) kind = 'region';
+			if (!kind) continue;
+			let name = clean(n.getAttribute('aria-label')) || textOfIds(n, n.getAttribute('aria-labelledby'));
+			if (!name) {
+				const heading = n.querySelector('h1, h2, h3, h4, h5, h6, [role="heading"]');
+				if (heading) name = clean(heading.textContent);
+			}
+			return name ? kind + " '" + name.slice(0, 40) + "'" : kind;
+		}
+		return '';
+	}
+	// The last heading before the element, for elements outside any landmark
+	function headingBefore(el) {
+		let top = el;
+		while (top.getRootNode() instanceof ShadowRoot) top = top.getRootNode().host;
+		if (!headings) headings = Array.from(document.querySelectorAll('h1, h2, h3, h4, h5, h6'));
+		let found = null;
+		for (const h of headings) {
+			if (h.compareDocumentPosition(top) & Node.DOCUMENT_POSITION_FOLLOWING) found = h;
+			else break;
+		}
+		const text = found && clean(found.textContent);
+		return text ? "under heading '" + text.slice(0, 40) + "'" : '';
+	}
+	function detailsOf(el) {
+		const r = el.getBoundingClientRect();
+		const details = {
+			box: { x: Math.round(r.left), y: Math.round(r.top), width: Math.round(r.width), height: Math.round(r.height) },
+			inViewport: r.width > 0 && r.height > 0 && r.bottom > 0 && r.right > 0 &&
+				r.top < window.innerHeight && r.left < window.innerWidth,
+			disabled: el.disabled || el.getAttribute('aria-disabled') === 'true' || undefined,
+			readonly: el.readOnly || el.getAttribute('aria-readonly') === 'true' || undefined,
+			required: el.required || el.getAttribute('aria-required') === 'true' || undefined,
+			context: landmarkOf(el) || headingBefore(el) || undefined
+		};
+		if (['INPUT', 'SELECT', 'TEXTAREA'].includes(el.tagName)) {
+			details.label = labelOf(el).slice(0, 50) || undefined;
+		}
+		if (el.type === 'checkbox' || el.type === 'radio') {
+			details.checked = String(el.checked);
+		} else if (el.hasAttribute('aria-checked')) {
+			details.checked = el.getAttribute('aria-checked');
+		}
+		if (el.hasAttribute('aria-expanded')) {
+			details.expanded = el.getAttribute('aria-expanded') === 'true';
+		}
+		const aria = {};
+		ariaStates.forEach(s => {
+			const v = el.getAttribute('aria-' + s);
+			if (v !== null && v !== 'false') aria[s] = v;
+		});
+		if (Object.keys(aria).length > 0) details.aria = aria;
+		return details;
+	}
+`
@@ -0,0 +1,258 @@
+package crawler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// DefaultFrozenTime is the clock a deterministic recording starts at when no
+// other time is given
+var DefaultFrozenTime = time.Date(2025, time.January, 15, 10, 30, 0, 0, time.UTC)
+
+// Determinism makes pages render the same on every recording
+type Determinism struct {
+	Time time.Time // What the page clock reads when the recording starts
+	Seed uint32    // Seed for Math.random and crypto.getRandomValues
+}
+
+// deterministicJS runs before any script of a document. It replaces the
+// clock with a virtual one and seeds the random number generators. The
+// virtual clock starts at a fixed time and only moves when AdvanceClock
+// moves it, once per recorded frame, so Date, performance.now, timers and
+// animation frames run the same on every recording however long capturing
+// and the AI take. Its position is kept in sessionStorage across
+// navigations, and iframes are advanced along with their parent.
+// It is completed with a JSON object of start and seed.
+const deterministicJS = `(config => {
+	if (window.__demogifDeterministic) return;
+	window.__demogifDeterministic = true;
+
+	const realSetTimeout = window.setTimeout.bind(window);
+	const rethrow = e => realSetTimeout(() => { throw e; }, 0);
+	const storageKey = '__demogifClock';
+	let elapsed = 0;
+	try {
+		elapsed = Number(sessionStorage.getItem(storageKey)) || 0;
+	} catch (e) {}
+	const documentStart = elapsed;
+
+	// Clock: the fixed start plus the virtual time advanced so far
+	const RealDate = Date;
+	const now = () => config.start + elapsed;
+	function FakeDate(...args) {
+		if (!new.target) return new RealDate(now()).toString();
+		return args.length ? new RealDate(...args) : new RealDate(now());
+	}
+	FakeDate.prototype = RealDate.prototype;
+	FakeDate.now = now;
+	FakeDate.parse = RealDate.parse;
+	FakeDate.UTC = RealDate.UTC;
+	Object.defineProperty(FakeDate.prototype, 'constructor', { value: FakeDate });
+	window.Date = FakeDate;
+	performance.now = () => elapsed - documentStart;
+
+	// Timers fire when virtual time reaches them. Zero delays still run
+	// right away; nested ones are clamped like browsers do, so a timer
+	// that keeps rescheduling itself can't stall an advance.
+	const timers = new Map();
+	let nextTimer = 1;
+	let nesting = 0;
+	const schedule = (fn, delay, args, repeat) => {
+		delay = Math.max(0, Number(delay) || 0);
+		if (nesting > 0 && delay < 4) delay = 4;
+		const id = nextTimer++;
+		timers.set(id, { fn, delay, args, repeat, due: elapsed + delay });
+		if (delay === 0) realSetTimeout(() => runTimers(elapsed), 0);
+		return id;
+	};
+	const runTimers = until => {
+		for (;;) {
+			let id = 0, timer = null;
+			for (const [i, t] of timers) {
+				if (t.due <= until && (!timer || t.due < timer.due)) {
+					id = i;
+					timer = t;
+				}
+			}
+			if (!timer) return;
+			elapsed = Math.max(elapsed, timer.due);
+			if (timer.repeat) {
+				timer.due = elapsed + Math.max(timer.delay, 4);
+			} else {
+				timers.delete(id);
+			}
+			nesting++;
+			try {
+				if (typeof timer.fn === 'function') timer.fn(...timer.args);
+				else (0, eval)(String(timer.fn));
+			} catch (e) {
+				rethrow(e);
+			} finally {
+				nesting--;
+			}
+		}
+	};
+	const clear = id => { timers.delete(id); };
+	window.setTimeout = (fn, delay, ...args) => schedule(fn, delay, args, false);
+	window.setInterval = (fn, delay, ...args) => schedule(fn, delay, args, true);
+	window.clearTimeout = clear;
+	window.clearInterval = clear;
+
+	// Animation frames run once per advance, i.e. once per recorded frame
+	const frameCallbacks = new Map();
+	let nextFrame = 1;
+	window.requestAnimationFrame = fn => {
+		const id = nextFrame++;
+		frameCallbacks.set(id, fn);
+		return id;
+	};
+	window.cancelAnimationFrame = id => { frameCallbacks.delete(id); };
+
+	const advance = ms => {
+		runTimers(elapsed + ms);
+		elapsed = Math.max(elapsed, Math.round((elapsed + ms) * 1000) / 1000);
+		const callbacks = Array.from(frameCallbacks.values());
+		frameCallbacks.clear();
+		const timestamp = performance.now();
+		for (const fn of callbacks) {
+			try {
+				fn(timestamp);
+			} catch (e) {
+				rethrow(e);
+			}
+		}
+		try {
+			sessionStorage.setItem(storageKey, String(elapsed));
+		} catch (e) {}
+		for (let i = 0; i < window.frames.length; i++) {
+			window.frames[i].postMessage({ __demogifAdvance: ms }, '*');
+		}
+	};
+	Object.defineProperty(window, '__demogifClock', { value: { advance } });
+
+	// Iframes follow their parent; the page never sees these messages
+	window.addEventListener('message', e => {
+		if (e.source !== window.parent || e.source === window || !e.data || typeof e.data.__demogifAdvance !== 'number') return;
+		e.stopImmediatePropagation();
+		advance(e.data.__demogifAdvance);
+	}, true);
+
+	// Randomness: mulberry32, seeded per document
+	let state = config.seed >>> 0;
+	const random = () => {
+		state = (state + 0x6D2B79F5) >>> 0;
+		let t = state;
+		t = Math.imul(t ^ (t >>> 15), t | 1);
+		t ^= t + Math.imul(t ^ (t >>> 7), t | 61);
+		return ((t ^ (t >>> 14)) >>> 0) / 4294967296;
+	};
+	Math.random = random;
+	if (window.crypto) {
+		crypto.getRandomValues = array => {
+			const bytes = new Uint8Array(array.buffer, array.byteOffset, array.byteLength);
+			for (let i = 0; i < bytes.length; i++) bytes[i] = Math.floor(random() * 256);
+			return array;
+		};
+		if (crypto.randomUUID) {
+			crypto.randomUUID = () => {
+				const b = crypto.getRandomValues(new Uint8Array(16));
+				b[6] = (b[6] & 0x0f) | 0x40;
+				b[8] = (b[8] & 0x3f) | 0x80;
+				const hex = Array.from(b, x => x.toString(16).padStart(2, '0')).join('');
+				return hex.slice(0, 8) + '-' + hex.slice(8, 12) + '-' + hex.slice(12, 16) + '-' + hex.slice(16, 20) + '-' + hex.slice(20);
+			};
+		}
+	}
+})`
+
+// deterministicCSS makes animations jump to their end state and stops the
+// caret from blinking, so it still shows where typing goes. Chrome only
+// supports caret-animation since version 139; older versions hide the caret
+// instead, since a blinking one differs from frame to frame.
+const deterministicCSS = `*, *::before, *::after {
+	animation-duration: 0s !important;
+	animation-delay: 0s !important;
+	animation-iteration-count: 1 !important;
+	transition-duration: 0s !important;
+	transition-delay: 0s !important;
+	caret-animation: manual !important;
+}
+@supports not (caret-animation: manual) {
+	*, *::before, *::after {
+		caret-color: transparent !important;
+	}
+}`
+
+// applyDeterminism installs the virtual clock, seeded randomness and disabled
+// animations in every document the page loads
+func applyDeterminism(page *rod.Page, d *Determinism) error {
+	start := d.Time
+	if start.IsZero() {
+		start = DefaultFrozenTime
+	}
+	config, err := json.Marshal(map[string]any{
+		"start": start.UnixMilli(),
+		"seed":  d.Seed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal determinism config: %w", err)
+	}
+	if _, err := page.EvalOnNewDocument(deterministicJS + "(" + string(config) + ");"); err != nil {
+		return fmt.Errorf("failed to install deterministic mode: %w", err)
+	}
+	if err := injectStyle(page, deterministicCSS); err != nil {
+		return err
+	}
+	deterministicTabs.Store(page.TargetID, true)
+	return nil
+}
+
+// deterministicTabs holds the tabs deterministic mode is installed in, so
+// AdvanceClock skips the round trip to all others
+var deterministicTabs sync.Map
+
+// AdvanceClock moves the virtual clock of deterministic mode forward by d,
+// running the timers and animation frames that fall due. Without
+// deterministic mode it does nothing.
+func AdvanceClock(page *rod.Page, d time.Duration) error {
+	if _, ok := deterministicTabs.Load(page.TargetID); !ok {
+		return nil
+	}
+	// Evaluated without a context, so it reaches the top-level document even
+	// when page is scoped to an iframe
+	_, err := proto.RuntimeEvaluate{
+		Expression: fmt.Sprintf("window.__demogifClock && window.__demogifClock.advance(%g)", float64(d)/float64(time.Millisecond)),
+	}.Call(page)
+	return err
+}
+
+// letTimePass waits for d, moving the virtual clock along with it
+func letTimePass(page *rod.Page, d time.Duration) {
+	time.Sleep(d)
+	_ = AdvanceClock(page, d)
+}
+
+// clockSleeper returns the sleeper rod waits for a page's elements with. In
+// deterministic mode each pause moves the virtual clock along, so elements
+// that the page's timers render can appear.
+func clockSleeper(page *rod.Page) func() utils.Sleeper {
+	if _, ok := deterministicTabs.Load(page.TargetID); !ok {
+		return rod.DefaultSleeper
+	}
+	return func() utils.Sleeper {
+		sleep := rod.DefaultSleeper()
+		return func(ctx context.Context) error {
+			start := time.Now()
+			err := sleep(ctx)
+			_ = AdvanceClock(page, time.Since(start))
+			return err
+		}
+	}
+}
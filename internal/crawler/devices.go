@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Device is an emulation preset: the viewport in CSS pixels, the device
+// pixel ratio and how the browser presents itself
+type Device struct {
+	Width     int
+	Height    int
+	DPR       float64
+	Mobile    bool
+	Touch     bool
+	UserAgent string
+}
+
+const (
+	iOSUserAgent     = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	iPadUserAgent    = "Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	androidUserAgent = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Mobile Safari/537.36"
+	tabletUserAgent  = "Mozilla/5.0 (Linux; Android 14; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+)
+
+// maxTouchPoints is what navigator.maxTouchPoints reports on touch devices
+const maxTouchPoints = 5
+
+// devices holds the presets for --device, keyed by lowercase name
+var devices = map[string]Device{
+	"iphone 15":         {Width: 393, Height: 852, DPR: 3, Mobile: true, Touch: true, UserAgent: iOSUserAgent},
+	"iphone 15 pro max": {Width: 430, Height: 932, DPR: 3, Mobile: true, Touch: true, UserAgent: iOSUserAgent},
+	"iphone se":         {Width: 375, Height: 667, DPR: 2, Mobile: true, Touch: true, UserAgent: iOSUserAgent},
+	"pixel 8":           {Width: 412, Height: 915, DPR: 2.625, Mobile: true, Touch: true, UserAgent: androidUserAgent},
+	"galaxy s23":        {Width: 360, Height: 780, DPR: 3, Mobile: true, Touch: true, UserAgent: androidUserAgent},
+	"ipad mini":         {Width: 744, Height: 1133, DPR: 2, Mobile: true, Touch: true, UserAgent: iPadUserAgent},
+	"ipad pro 11":       {Width: 834, Height: 1194, DPR: 2, Mobile: true, Touch: true, UserAgent: iPadUserAgent},
+	"galaxy tab s9":     {Width: 800, Height: 1280, DPR: 2, Mobile: true, Touch: true, UserAgent: tabletUserAgent},
+	"desktop hidpi":     {Width: 1280, Height: 720, DPR: 2},
+	"macbook pro 14":    {Width: 1512, Height: 982, DPR: 2},
+}
+
+// LookupDevice returns the preset with the given name, ignoring case
+func LookupDevice(name string) (Device, error) {
+	d, ok := devices[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return Device{}, fmt.Errorf("unknown device %q (available: %s)", name, strings.Join(DeviceNames(), ", "))
+	}
+	return d, nil
+}
+
+// DeviceNames lists the device presets
+func DeviceNames() []string {
+	names := make([]string, 0, len(devices))
+	for name := range devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// emulatesDevice reports whether the page needs device emulation before
+// its first request, beyond the default desktop viewport
+func (o Options) emulatesDevice() bool {
+	return (o.DPR != 0 && o.DPR != 1) || o.Mobile || o.Touch || o.UserAgent != ""
+}
+
+// applyEmulation sets the viewport, device pixel ratio, mobile mode, touch
+// support and user agent of the page, along with the language the browser
+// reports for Options.Locale
+func applyEmulation(page *rod.Page, opts Options) error {
+	dpr := opts.DPR
+	if dpr == 0 {
+		dpr = 1
+	}
+	err := proto.EmulationSetDeviceMetricsOverride{
+		Width:             opts.Width,
+		Height:            opts.Height,
+		DeviceScaleFactor: dpr,
+		Mobile:            opts.Mobile,
+	}.Call(page)
+	if err != nil {
+		return fmt.Errorf("failed to set viewport: %w", err)
+	}
+
+	if opts.Touch {
+		points := maxTouchPoints
+		err := proto.EmulationSetTouchEmulationEnabled{Enabled: true, MaxTouchPoints: &points}.Call(page)
+		if err != nil {
+			return fmt.Errorf("failed to enable touch: %w", err)
+		}
+	}
+
+	if opts.UserAgent != "" || opts.Locale != "" {
+		override := &proto.NetworkSetUserAgentOverride{UserAgent: opts.UserAgent, AcceptLanguage: opts.Locale}
+		if override.UserAgent == "" {
+			// Only the language changes, keep the browser's own user agent
+			res, err := page.Eval(`() => navigator.userAgent`)
+			if err != nil {
+				return fmt.Errorf("failed to read user agent: %w", err)
+			}
+			override.UserAgent = res.Value.String()
+		}
+		if err := page.SetUserAgent(override); err != nil {
+			return fmt.Errorf("failed to set user agent: %w", err)
+		}
+	}
+
+	return nil
+}
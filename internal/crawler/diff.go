@@ -0,0 +1,227 @@
+package crawler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PageDiff describes how a page changed between two crawls
+type PageDiff struct {
+	OldURL   string
+	NewURL   string
+	OldTitle string
+	NewTitle string
+	Added    []Element
+	Removed  []Element
+	Changed  []ElementChange
+
+	// Landmarks and headings that had elements in each crawl
+	oldContexts map[string]bool
+	newContexts map[string]bool
+}
+
+// ElementChange is an element present in both crawls whose state changed
+type ElementChange struct {
+	Element Element  // The element as it is now
+	Changes []string // e.g. `text "Save" → "Saved"`, "now disabled"
+}
+
+// Diff compares two page maps. Elements are matched by selector and frame.
+func Diff(old, new *PageMap) *PageDiff {
+	d := &PageDiff{
+		OldURL:   old.URL,
+		NewURL:   new.URL,
+		OldTitle: old.Title,
+		NewTitle: new.Title,
+
+		oldContexts: map[string]bool{},
+		newContexts: map[string]bool{},
+	}
+
+	before := make(map[string]Element, len(old.Elements))
+	for _, el := range old.Elements {
+		before[elementKey(el)] = el
+		d.oldContexts[el.Context] = true
+	}
+	after := make(map[string]bool, len(new.Elements))
+	for _, el := range new.Elements {
+		key := elementKey(el)
+		after[key] = true
+		d.newContexts[el.Context] = true
+		prev, ok := before[key]
+		if !ok {
+			d.Added = append(d.Added, el)
+			continue
+		}
+		if changes := elementChanges(prev, el); len(changes) > 0 {
+			d.Changed = append(d.Changed, ElementChange{Element: el, Changes: changes})
+		}
+	}
+	for _, el := range old.Elements {
+		if !after[elementKey(el)] {
+			d.Removed = append(d.Removed, el)
+		}
+	}
+
+	return d
+}
+
+// elementKey identifies an element across crawls
+func elementKey(el Element) string {
+	return strings.Join(el.Frame, ShadowSeparator) + "\x00" + el.Selector
+}
+
+// elementChanges lists the differences in what the user can see of an element
+func elementChanges(old, new Element) []string {
+	var changes []string
+	if old.Text != new.Text {
+		changes = append(changes, fmt.Sprintf("text %q → %q", old.Text, new.Text))
+	}
+	if old.Disabled != new.Disabled {
+		changes = append(changes, "now "+flag(new.Disabled, "disabled", "enabled"))
+	}
+	if old.Checked != new.Checked && new.Checked != "" {
+		changes = append(changes, "checked "+new.Checked)
+	}
+	if old.Expanded != nil && new.Expanded != nil && *old.Expanded != *new.Expanded {
+		changes = append(changes, "now "+flag(*new.Expanded, "expanded", "collapsed"))
+	}
+	if old.Hidden != new.Hidden {
+		changes = append(changes, "now "+flag(new.Hidden, "hidden", "visible"))
+	}
+	if old.Context != new.Context {
+		changes = append(changes, fmt.Sprintf("moved to %s", orNone(new.Context)))
+	}
+	return changes
+}
+
+// Empty reports whether nothing changed between the crawls
+func (d *PageDiff) Empty() bool {
+	return d.OldURL == d.NewURL && d.OldTitle == d.NewTitle &&
+		len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Summary describes the changes in a few lines, grouping added and removed
+// elements by the landmark or heading they belong to, e.g.
+// "dialog 'New set' opened with 4 inputs, 2 buttons"
+func (d *PageDiff) Summary() []string {
+	var lines []string
+	if d.OldURL != d.NewURL {
+		lines = append(lines, fmt.Sprintf("URL changed: %s → %s", d.OldURL, d.NewURL))
+	}
+	if d.OldTitle != d.NewTitle {
+		lines = append(lines, fmt.Sprintf("title changed: %q → %q", d.OldTitle, d.NewTitle))
+	}
+
+	// A context that only exists in one crawl opened or closed as a whole
+	for _, g := range groupByContext(d.Added) {
+		switch {
+		case g.context == "":
+			lines = append(lines, fmt.Sprintf("%s added", g.counts))
+		case !d.oldContexts[g.context] && d.OldURL == d.NewURL:
+			lines = append(lines, fmt.Sprintf("%s opened with %s", g.context, g.counts))
+		default:
+			lines = append(lines, fmt.Sprintf("%s added in %s", g.counts, g.context))
+		}
+	}
+	for _, g := range groupByContext(d.Removed) {
+		switch {
+		case g.context == "":
+			lines = append(lines, fmt.Sprintf("%s removed", g.counts))
+		case !d.newContexts[g.context] && d.OldURL == d.NewURL:
+			lines = append(lines, fmt.Sprintf("%s closed (%s gone)", g.context, g.counts))
+		default:
+			lines = append(lines, fmt.Sprintf("%s removed from %s", g.counts, g.context))
+		}
+	}
+	for _, c := range d.Changed {
+		lines = append(lines, fmt.Sprintf("%s %s: %s", c.Element.Type, c.Element.Selector, strings.Join(c.Changes, ", ")))
+	}
+
+	return lines
+}
+
+// String is the summary as one block of text
+func (d *PageDiff) String() string {
+	if d.Empty() {
+		return "nothing changed"
+	}
+	return strings.Join(d.Summary(), "\n")
+}
+
+// contextGroup counts the kinds of element in one landmark or heading
+type contextGroup struct {
+	context string
+	counts  string // e.g. "4 inputs, 2 buttons"
+}
+
+// groupByContext groups elements by context in order of first appearance
+func groupByContext(elements []Element) []contextGroup {
+	var order []string
+	kinds := map[string]map[string]int{}
+	for _, el := range elements {
+		if kinds[el.Context] == nil {
+			kinds[el.Context] = map[string]int{}
+			order = append(order, el.Context)
+		}
+		kinds[el.Context][elementKind(el)]++
+	}
+
+	groups := make([]contextGroup, 0, len(order))
+	for _, context := range order {
+		var names []string
+		for kind := range kinds[context] {
+			names = append(names, kind)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			a, b := kinds[context][names[i]], kinds[context][names[j]]
+			if a != b {
+				return a > b
+			}
+			return names[i] < names[j]
+		})
+		parts := make([]string, len(names))
+		for i, kind := range names {
+			parts[i] = plural(kinds[context][kind], kind)
+		}
+		groups = append(groups, contextGroup{context: context, counts: strings.Join(parts, ", ")})
+	}
+	return groups
+}
+
+// elementKind names an element type for summaries, folding the input types
+func elementKind(el Element) string {
+	switch el.Type {
+	case "button", "link", "select", "checkbox", "radio", "file", "tab", "option", "menuitem", "switch", "slider":
+		return el.Type
+	}
+	return "input"
+}
+
+// plural formats a count with its noun, e.g. "1 input", "3 checkboxes"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	if strings.HasSuffix(noun, "x") || strings.HasSuffix(noun, "ch") {
+		return fmt.Sprintf("%d %ses", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// flag picks the word for a boolean state
+func flag(b bool, yes, no string) string {
+	if b {
+		return yes
+	}
+	return no
+}
+
+// orNone shows an empty context as "no landmark"
+func orNone(context string) string {
+	if context == "" {
+		return "no landmark"
+	}
+	return context
+}
@@ -0,0 +1,43 @@
+package crawler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// maxFrameDepth limits how deeply nested iframes are crawled
+const maxFrameDepth = 3
+
+// EnterFrame returns a page scoped to the iframe reached by following the
+// frame path (iframe selectors, outermost first). Mouse, keyboard and
+// screenshots still act on the whole tab; queries and evals run in the frame.
+// Out-of-process iframes, which Chrome creates for cross-site content unless
+// launched with Options.DisableSiteIsolation, can't be entered and return an
+// error.
+func EnterFrame(page *rod.Page, path []string) (*rod.Page, error) {
+	ctx := page.GetContext()
+	for _, selector := range path {
+		el, err := FindElement(page.Timeout(5*time.Second), selector)
+		if err != nil {
+			return nil, fmt.Errorf("frame not found: %s", selector)
+		}
+		// rod reaches into the content document of the iframe, which an
+		// out-of-process one doesn't have in this tab
+		node, err := el.Describe(1, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot enter frame %s: %w", selector, err)
+		}
+		if node.ContentDocument == nil {
+			return nil, fmt.Errorf("cannot enter frame %s: its content runs in another process because of site isolation", selector)
+		}
+		frame, err := el.Frame()
+		if err != nil {
+			return nil, fmt.Errorf("cannot enter frame %s: %w", selector, err)
+		}
+		// Drop the lookup timeout, which the frame page would otherwise inherit
+		page = frame.Context(ctx)
+	}
+	return page, nil
+}
@@ -0,0 +1,393 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// HAR is an HTTP Archive (HAR 1.2) of the responses a page loaded. Only the
+// fields needed to replay a session are filled in.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of a HAR file
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the tool that wrote the HAR
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is one request and its response
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is the request of a HAR entry
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	Cookies     []HARNameValue `json:"cookies"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARPostData is the body of a request
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARResponse is the response of a HAR entry
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Headers     []HARNameValue `json:"headers"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARContent is the body of a response
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"` // "base64" for binary bodies
+}
+
+// HARNameValue is a header, query parameter or cookie
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARTimings is required by the format; recorded entries leave it at zero
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Body decodes the response body
+func (c HARContent) Body() ([]byte, error) {
+	if c.Encoding == "base64" {
+		return base64.StdEncoding.DecodeString(c.Text)
+	}
+	return []byte(c.Text), nil
+}
+
+// LoadHAR reads a HAR file
+func LoadHAR(path string) (*HAR, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HAR: %w", err)
+	}
+	var har HAR
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("failed to parse HAR %s: %w", path, err)
+	}
+	return &har, nil
+}
+
+// Save writes the HAR to a file. Recorded sessions can hold cookies and
+// tokens in their headers, so only the current user can read it.
+func (h *HAR) Save(path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write HAR: %w", err)
+	}
+	return nil
+}
+
+// harRecorder collects the responses of a page into HAR entries
+type harRecorder struct {
+	mu      sync.Mutex
+	pending map[proto.NetworkRequestID]*HAREntry
+	entries []HAREntry
+}
+
+// recordHAR starts recording every response the page receives
+func recordHAR(page *rod.Page) (*harRecorder, error) {
+	if err := (proto.NetworkEnable{}).Call(page); err != nil {
+		return nil, fmt.Errorf("failed to enable network recording: %w", err)
+	}
+
+	r := &harRecorder{pending: map[proto.NetworkRequestID]*HAREntry{}}
+	go page.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		if e.RedirectResponse != nil {
+			// The same request ID continues at the redirect target
+			r.finish(e.RequestID, e.RedirectResponse, nil)
+		}
+		if !strings.HasPrefix(e.Request.URL, "http") {
+			return // data:, blob: and the like need no replaying
+		}
+		entry := &HAREntry{
+			StartedDateTime: e.WallTime.Time().UTC().Format(time.RFC3339Nano),
+			Request:         harRequest(e.Request),
+		}
+		if e.Request.HasPostData && entry.Request.PostData == nil {
+			if res, err := (proto.NetworkGetRequestPostData{RequestID: e.RequestID}).Call(page); err == nil {
+				entry.Request.PostData = &HARPostData{MimeType: headerValue(e.Request.Headers, "Content-Type"), Text: res.PostData}
+				entry.Request.BodySize = len(res.PostData)
+			}
+		}
+		r.mu.Lock()
+		r.pending[e.RequestID] = entry
+		r.mu.Unlock()
+	}, func(e *proto.NetworkResponseReceived) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if entry := r.pending[e.RequestID]; entry != nil {
+			entry.Response = harResponse(e.Response)
+		}
+	}, func(e *proto.NetworkLoadingFinished) {
+		body, err := (proto.NetworkGetResponseBody{RequestID: e.RequestID}).Call(page)
+		if err != nil {
+			body = nil // e.g. an empty 204, or evicted from the buffer
+		}
+		r.finish(e.RequestID, nil, body)
+	}, func(e *proto.NetworkLoadingFailed) {
+		r.mu.Lock()
+		delete(r.pending, e.RequestID)
+		r.mu.Unlock()
+	})()
+
+	return r, nil
+}
+
+// finish moves a pending request to the recorded entries. A redirect
+// response completes it without a body.
+func (r *harRecorder) finish(id proto.NetworkRequestID, redirect *proto.NetworkResponse, body *proto.NetworkGetResponseBodyResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.pending[id]
+	if entry == nil {
+		return
+	}
+	delete(r.pending, id)
+
+	if redirect != nil {
+		entry.Response = harResponse(redirect)
+		entry.Response.RedirectURL = headerValue(redirect.Headers, "Location")
+	}
+	if body != nil {
+		entry.Response.Content.Text = body.Body
+		entry.Response.Content.Size = len(body.Body)
+		if body.Base64Encoded {
+			entry.Response.Content.Encoding = "base64"
+			entry.Response.Content.Size = base64.StdEncoding.DecodedLen(len(body.Body))
+		}
+	}
+	if entry.Response.Status == 0 {
+		return // Never got a response, e.g. cancelled
+	}
+	r.entries = append(r.entries, *entry)
+}
+
+// HAR returns the responses recorded so far
+func (r *harRecorder) HAR() *HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// In the order responses completed, which replay serves repeats in
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "demogif", Version: "1"},
+		Entries: append([]HAREntry{}, r.entries...),
+	}}
+}
+
+// harRequest converts a CDP request
+func harRequest(req *proto.NetworkRequest) HARRequest {
+	r := HARRequest{
+		Method:      req.Method,
+		URL:         req.URL,
+		HTTPVersion: "HTTP/1.1",
+		Headers:     harHeaders(req.Headers),
+		QueryString: []HARNameValue{},
+		Cookies:     []HARNameValue{},
+		HeadersSize: -1,
+	}
+	if u, err := url.Parse(req.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, v := range values {
+				r.QueryString = append(r.QueryString, HARNameValue{Name: name, Value: v})
+			}
+		}
+	}
+	if body := postData(req); body != "" {
+		r.PostData = &HARPostData{MimeType: headerValue(req.Headers, "Content-Type"), Text: body}
+		r.BodySize = len(body)
+	}
+	return r
+}
+
+// harResponse converts a CDP response, without its body
+func harResponse(res *proto.NetworkResponse) HARResponse {
+	version := strings.ToUpper(res.Protocol)
+	if version == "" || !strings.HasPrefix(version, "HTTP") {
+		version = "HTTP/1.1"
+	}
+	return HARResponse{
+		Status:      res.Status,
+		StatusText:  res.StatusText,
+		HTTPVersion: version,
+		Headers:     harHeaders(res.Headers),
+		Cookies:     []HARNameValue{},
+		Content:     HARContent{MimeType: res.MIMEType},
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+}
+
+// harHeaders converts CDP headers, sorted by name
+func harHeaders(headers proto.NetworkHeaders) []HARNameValue {
+	list := make([]HARNameValue, 0, len(headers))
+	for name, value := range headers {
+		list = append(list, HARNameValue{Name: name, Value: value.String()})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// headerValue returns a header of a CDP request or response, ignoring case
+func headerValue(headers proto.NetworkHeaders, name string) string {
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v.String()
+		}
+	}
+	return ""
+}
+
+// postData returns the body of a request, which newer Chrome versions only
+// send as entries
+func postData(req *proto.NetworkRequest) string {
+	if req.PostData != "" {
+		return req.PostData
+	}
+	var b strings.Builder
+	for _, entry := range req.PostDataEntries {
+		b.Write(entry.Bytes)
+	}
+	return b.String()
+}
+
+// harReplayer serves recorded responses. Requests repeated during the
+// session, such as polling, get the recorded responses in order, and the
+// last one once they run out.
+type harReplayer struct {
+	mu      sync.Mutex
+	entries map[string][]*HAREntry
+	served  map[string]int
+}
+
+// newHARReplayer indexes the entries of a HAR by method, URL and body
+func newHARReplayer(har *HAR) *harReplayer {
+	r := &harReplayer{entries: map[string][]*HAREntry{}, served: map[string]int{}}
+	for i := range har.Log.Entries {
+		e := &har.Log.Entries[i]
+		body := ""
+		if e.Request.PostData != nil {
+			body = e.Request.PostData.Text
+		}
+		for _, key := range []string{replayKey(e.Request.Method, e.Request.URL, body), replayKey(e.Request.Method, e.Request.URL, "")} {
+			r.entries[key] = append(r.entries[key], e)
+			if body == "" {
+				break
+			}
+		}
+	}
+	return r
+}
+
+// replayKey identifies a request. The fragment never reaches the server.
+func replayKey(method, rawURL, body string) string {
+	if i := strings.IndexByte(rawURL, '#'); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	return method + " " + rawURL + "\n" + body
+}
+
+// match finds the recorded response for a request, preferring one with the
+// same body so different GraphQL queries to one endpoint replay correctly
+func (r *harReplayer) match(req *proto.NetworkRequest) *HAREntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := []string{replayKey(req.Method, req.URL, "")}
+	if body := postData(req); body != "" {
+		keys = append([]string{replayKey(req.Method, req.URL, body)}, keys...)
+	}
+	for _, key := range keys {
+		entries := r.entries[key]
+		if len(entries) == 0 {
+			continue
+		}
+		i := r.served[key]
+		if i >= len(entries) {
+			i = len(entries) - 1
+		}
+		r.served[key]++
+		return entries[i]
+	}
+	return nil
+}
+
+// fulfill answers a paused request with a recorded response
+func (e *HAREntry) fulfill(page *rod.Page, id proto.FetchRequestID) error {
+	body, err := e.Response.Content.Body()
+	if err != nil {
+		return err
+	}
+	headers := make([]*proto.FetchHeaderEntry, 0, len(e.Response.Headers))
+	for _, h := range e.Response.Headers {
+		// The recorded body is already decoded and may differ in length
+		if strings.EqualFold(h.Name, "Content-Encoding") || strings.EqualFold(h.Name, "Content-Length") || strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		// Chrome joins repeated headers such as Set-Cookie with newlines
+		for _, value := range strings.Split(h.Value, "\n") {
+			headers = append(headers, &proto.FetchHeaderEntry{Name: h.Name, Value: value})
+		}
+	}
+	return proto.FetchFulfillRequest{
+		RequestID:       id,
+		ResponseCode:    e.Response.Status,
+		ResponseHeaders: headers,
+		Body:            body,
+		ResponsePhrase:  e.Response.StatusText,
+	}.Call(page)
+}
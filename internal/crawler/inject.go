@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-rod/rod"
+)
+
+// Injection is a user stylesheet or script added to every document the
+// page loads, e.g. to hide a staging banner or set a feature flag
+type Injection struct {
+	Name   string // File name, shown in the console and stack traces
+	Source string
+	CSS    bool // A stylesheet rather than a script
+}
+
+// LoadInjection reads a stylesheet or script to inject
+func LoadInjection(path string, css bool) (Injection, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Injection{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return Injection{Name: filepath.Base(path), Source: string(data), CSS: css}, nil
+}
+
+// applyInjections adds the injections to every document the page loads,
+// before the page's own scripts run. They are registered on the page, so
+// they also apply after navigations by actions and at checkpoints.
+func applyInjections(page *rod.Page, injections []Injection) error {
+	for _, inj := range injections {
+		if inj.CSS {
+			if err := injectStyle(page, inj.Source); err != nil {
+				return fmt.Errorf("failed to inject %s: %w", inj.Name, err)
+			}
+			continue
+		}
+		// Name the script so errors in it point at the file
+		if _, err := page.EvalOnNewDocument(inj.Source + "\n//# sourceURL=" + inj.Name + "\n"); err != nil {
+			return fmt.Errorf("failed to inject %s: %w", inj.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package crawler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// Locator describes an element in ways that survive UI refactors better
+// than a CSS selector. The strategies are tried in field order, then the
+// element's CSS selector.
+type Locator struct {
+	TestID string `json:"testId,omitempty"` // data-testid or data-test attribute
+	Role   string `json:"role,omitempty"`   // ARIA role, used together with Name
+	Name   string `json:"name,omitempty"`   // Accessible name
+	Text   string `json:"text,omitempty"`   // Exact visible text
+}
+
+// locatorLookupTimeout bounds how long Find retries its strategies
+const locatorLookupTimeout = 10 * time.Second
+
+// LocatorJS defines JS helpers that describe an element as a Locator. It is
+// meant to be placed after DeepQueryJS in a function body passed to Eval:
+//
+//	locatorFor(el)          the locator of an element, or undefined
+//	pruneLocators(elements) drops strategies shared by several elements
+const LocatorJS = `
+	const locatorTargets = 'a[href], button, [role], input, select, textarea';
+	function normalize(s) {
+		return (s || '').replace(/\s+/g, ' ').trim();
+	}
+	function roleOf(el) {
+		const explicit = el.getAttribute('role');
+		if (explicit) return explicit.trim().split(/\s+/)[0];
+		switch (el.tagName) {
+		case 'A': return 'link';
+		case 'BUTTON': return 'button';
+		case 'TEXTAREA': return 'textbox';
+		case 'SELECT': return el.multiple || el.size > 1 ? 'listbox' : 'combobox';
+		case 'INPUT':
+			switch ((el.type || 'text').toLowerCase()) {
+			case 'button': case 'submit': case 'reset': case 'image': return 'button';
+			case 'checkbox': return 'checkbox';
+			case 'radio': return 'radio';
+			case 'search': return 'searchbox';
+			case 'range': return 'slider';
+			case 'number': return 'spinbutton';
+			case 'text': case 'email': case 'tel': case 'url': return 'textbox';
+			}
+		}
+		return '';
+	}
+	function nameOf(el) {
+		const label = normalize(el.getAttribute('aria-label'));
+		if (label) return label;
+		const ids = el.getAttribute('aria-labelledby');
+		if (ids) {
+			const root = el.getRootNode();
+			const text = normalize(ids.split(/\s+/)
+				.map(id => root.getElementById(id))
+				.filter(Boolean)
+				.map(n => n.textContent)
+				.join(' '));
+			if (text) return text;
+		}
+		if (el.labels && el.labels.length > 0) {
+			const text = normalize(Array.from(el.labels).map(l => l.textContent).join(' '));
+			if (text) return text;
+		}
+		if (el.tagName === 'INPUT' && ['button', 'submit', 'reset'].includes(el.type)) {
+			return normalize(el.value);
+		}
+		if (el.tagName === 'A' || el.tagName === 'BUTTON' || el.getAttribute('role')) {
+			const text = normalize(el.textContent);
+			if (text) return text;
+		}
+		return normalize(el.title || el.placeholder);
+	}
+	function textOf(el) {
+		if (el.tagName === 'INPUT') {
+			return ['button', 'submit', 'reset'].includes(el.type) ? normalize(el.value) : '';
+		}
+		if (el.tagName === 'SELECT' || el.tagName === 'TEXTAREA') return '';
+		return normalize(el.innerText);
+	}
+	function locatorFor(el) {
+		const locator = {};
+		const testId = el.getAttribute('data-testid') || el.getAttribute('data-test');
+		if (testId) locator.testId = testId;
+		const role = roleOf(el);
+		const name = nameOf(el);
+		if (role && name && name.length <= 80) {
+			locator.role = role;
+			locator.name = name;
+		}
+		const text = textOf(el);
+		if (text && text.length <= 50) locator.text = text;
+		return Object.keys(locator).length > 0 ? locator : undefined;
+	}
+	function pruneLocators(elements) {
+		const counts = new Map();
+		const keys = l => [
+			l.testId && 't:' + l.testId,
+			l.role && 'r:' + l.role + '\u0000' + l.name,
+			l.text && 'x:' + l.text
+		].filter(Boolean);
+		elements.forEach(e => e.locator && keys(e.locator).forEach(k => counts.set(k, (counts.get(k) || 0) + 1)));
+		elements.forEach(e => {
+			const l = e.locator;
+			if (!l) return;
+			if (l.testId && counts.get('t:' + l.testId) > 1) delete l.testId;
+			if (l.role && counts.get('r:' + l.role + '\u0000' + l.name) > 1) { delete l.role; delete l.name; }
+			if (l.text && counts.get('x:' + l.text) > 1) delete l.text;
+			if (Object.keys(l).length === 0) delete e.locator;
+		});
+	}
+`
+
+// findByTestIDJS finds the visible element with a data-testid or data-test
+const findByTestIDJS = `(id) => {` + DeepQueryJS + `
+	return deepQueryAll('[data-testid], [data-test]').find(el =>
+		(el.getAttribute('data-testid') === id || el.getAttribute('data-test') === id) &&
+		el.getClientRects().length > 0) || null;
+}`
+
+// findByTextJS finds the visible interactive element with the exact text
+const findByTextJS = `(text) => {` + DeepQueryJS + LocatorJS + `
+	return deepQueryAll(locatorTargets).find(el =>
+		el.getClientRects().length > 0 && textOf(el) === text) || null;
+}`
+
+// Find resolves the locator, trying each strategy in order of preference and
+// falling back to the CSS selector. It returns the strategy that matched:
+// "testid", "role", "text" or "css".
+func (l *Locator) Find(page *rod.Page, selector string) (*rod.Element, string, error) {
+	// Each strategy is tried once per round so a stale preferred strategy
+	// doesn't hold up a working fallback. Elements found this way get the
+	// default sleeper back so later waits on them still retry.
+	quick := page.Sleeper(rod.NotFoundSleeper)
+
+	deadline := time.Now().Add(locatorLookupTimeout)
+	for {
+		if l.TestID != "" {
+			if el, err := quick.ElementByJS(rod.Eval(findByTestIDJS, l.TestID)); err == nil {
+				return el.Sleeper(rod.DefaultSleeper), "testid", nil
+			}
+		}
+		if l.Role != "" && l.Name != "" {
+			if el, err := queryRole(page, l.Role, l.Name, 0); err == nil {
+				return el, "role", nil
+			}
+		}
+		if l.Text != "" {
+			if el, err := quick.ElementByJS(rod.Eval(findByTextJS, l.Text)); err == nil {
+				return el.Sleeper(rod.DefaultSleeper), "text", nil
+			}
+		}
+		if selector != "" {
+			if el, err := findElementOnce(page, selector); err == nil {
+				return el.Sleeper(rod.DefaultSleeper), "css", nil
+			}
+		}
+
+		if time.Now().After(deadline) {
+			return nil, "", fmt.Errorf("no element matches %s", l.describe(selector))
+		}
+		letTimePass(page, 200*time.Millisecond)
+	}
+}
+
+// describe lists the strategies of the locator for error messages
+func (l *Locator) describe(selector string) string {
+	var parts []string
+	if l.TestID != "" {
+		parts = append(parts, fmt.Sprintf("testid %q", l.TestID))
+	}
+	if l.Role != "" && l.Name != "" {
+		parts = append(parts, fmt.Sprintf("role %s named %q", l.Role, l.Name))
+	}
+	if l.Text != "" {
+		parts = append(parts, fmt.Sprintf("text %q", l.Text))
+	}
+	if selector != "" {
+		parts = append(parts, selector)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// findElementOnce is FindElement without waiting for the element to appear
+func findElementOnce(page *rod.Page, selector string) (*rod.Element, error) {
+	if strings.HasPrefix(selector, "role=") {
+		role, name, nth, err := parseRoleLocator(selector)
+		if err != nil {
+			return nil, err
+		}
+		return queryRole(page, role, name, nth)
+	}
+	return queryElement(page.Sleeper(rod.NotFoundSleeper), selector)
+}
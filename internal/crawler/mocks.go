@@ -0,0 +1,125 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Mock is a canned response for the requests matching a URL pattern
+type Mock struct {
+	URL      string            `json:"url"`              // Full URL with * wildcards, e.g. "*/api/projects*"
+	Method   string            `json:"method,omitempty"` // Any method if empty
+	Status   int               `json:"status,omitempty"` // 200 if empty
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     string            `json:"body,omitempty"`
+	JSON     json.RawMessage   `json:"json,omitempty"`     // Response body as JSON, sets Content-Type
+	BodyFile string            `json:"bodyFile,omitempty"` // File served as the body, relative to the mocks file
+
+	pattern *regexp.Regexp
+	body    []byte
+}
+
+// LoadMocks reads a JSON array of mocks
+func LoadMocks(path string) ([]Mock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mocks: %w", err)
+	}
+	var mocks []Mock
+	if err := json.Unmarshal(data, &mocks); err != nil {
+		return nil, fmt.Errorf("failed to parse mocks %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	for i := range mocks {
+		m := &mocks[i]
+		if m.URL == "" {
+			return nil, fmt.Errorf("mock %d in %s has no url", i+1, path)
+		}
+		m.pattern = globPattern(m.URL)
+		m.Method = strings.ToUpper(m.Method)
+		if m.Status == 0 {
+			m.Status = 200
+		}
+
+		switch {
+		case m.BodyFile != "":
+			file := m.BodyFile
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			if m.body, err = os.ReadFile(file); err != nil {
+				return nil, fmt.Errorf("mock %d in %s: %w", i+1, path, err)
+			}
+			m.setDefaultHeader("Content-Type", mime.TypeByExtension(filepath.Ext(file)))
+		case len(m.JSON) > 0:
+			m.body = m.JSON
+			m.setDefaultHeader("Content-Type", "application/json")
+		default:
+			m.body = []byte(m.Body)
+		}
+		m.setDefaultHeader("Content-Type", "text/plain; charset=utf-8")
+	}
+	return mocks, nil
+}
+
+// globPattern compiles a URL pattern where * matches anything
+func globPattern(glob string) *regexp.Regexp {
+	parts := strings.Split(glob, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// setDefaultHeader sets a header unless the mock already has it
+func (m *Mock) setDefaultHeader(name, value string) {
+	if value == "" {
+		return
+	}
+	for k := range m.Headers {
+		if strings.EqualFold(k, name) {
+			return
+		}
+	}
+	if m.Headers == nil {
+		m.Headers = map[string]string{}
+	}
+	m.Headers[name] = value
+}
+
+// matches reports whether the mock answers a request
+func (m *Mock) matches(req *proto.NetworkRequest) bool {
+	if m.Method != "" && m.Method != req.Method {
+		return false
+	}
+	return m.pattern.MatchString(req.URL)
+}
+
+// fulfill answers a paused request with the mock
+func (m *Mock) fulfill(page *rod.Page, id proto.FetchRequestID) error {
+	names := make([]string, 0, len(m.Headers))
+	for name := range m.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := make([]*proto.FetchHeaderEntry, len(names))
+	for i, name := range names {
+		headers[i] = &proto.FetchHeaderEntry{Name: name, Value: m.Headers[name]}
+	}
+	return proto.FetchFulfillRequest{
+		RequestID:       id,
+		ResponseCode:    m.Status,
+		ResponseHeaders: headers,
+		Body:            m.body,
+	}.Call(page)
+}
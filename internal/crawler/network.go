@@ -0,0 +1,187 @@
+package crawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// maxAuthAttempts is how often a challenge for one URL is answered before
+// giving up on credentials that don't work
+const maxAuthAttempts = 2
+
+// proxyServer splits a proxy URL into the address passed to Chrome and the
+// credentials embedded in it, which Chrome's --proxy-server doesn't accept
+func proxyServer(proxy string) (string, *url.Userinfo) {
+	u, err := url.Parse(proxy)
+	if err != nil || u.Host == "" {
+		// Plain host:port
+		return proxy, nil
+	}
+	creds := u.User
+	u.User = nil
+	return u.String(), creds
+}
+
+// applyNetworkOptions sends the extra headers with the requests to the
+// origin of target, answers HTTP auth challenges from servers and the proxy,
+// blocks URLs, and serves mocks and replayed responses. When recording, it
+// returns the recorder the session's responses are collected in.
+func applyNetworkOptions(page *rod.Page, target string, opts Options) (*harRecorder, error) {
+	// Headers often carry tokens, which third parties mustn't see
+	var headers *headerScope
+	if len(opts.Headers) > 0 {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set headers: %w", err)
+		}
+		headers = &headerScope{origin: origin(u), headers: opts.Headers}
+	}
+
+	var recorder *harRecorder
+	if opts.RecordHAR {
+		var err error
+		if recorder, err = recordHAR(page); err != nil {
+			return nil, err
+		}
+	}
+
+	var server, proxy *url.Userinfo
+	if opts.HTTPUser != "" {
+		server = url.UserPassword(opts.HTTPUser, opts.HTTPPassword)
+	}
+	if opts.Proxy != "" {
+		_, proxy = proxyServer(opts.Proxy)
+	}
+	var replayer *harReplayer
+	if opts.ReplayHAR != nil {
+		replayer = newHARReplayer(opts.ReplayHAR)
+	}
+	var blocked []*regexp.Regexp
+	for _, u := range opts.blockedURLs() {
+		blocked = append(blocked, globPattern(u))
+	}
+	if server == nil && proxy == nil && replayer == nil && headers == nil && len(blocked) == 0 && len(opts.Mocks) == 0 {
+		return recorder, nil
+	}
+
+	// One Fetch handler for everything, since enabling the domain again
+	// would replace the earlier patterns
+	enable := proto.FetchEnable{HandleAuthRequests: server != nil || proxy != nil}
+	if err := enable.Call(page); err != nil {
+		return nil, fmt.Errorf("failed to enable request interception: %w", err)
+	}
+	attempts := map[string]int{}
+	go page.EachEvent(func(e *proto.FetchRequestPaused) {
+		if err := interceptRequest(page, e, opts, blocked, replayer, headers); err != nil {
+			_ = proto.FetchFailRequest{RequestID: e.RequestID, ErrorReason: proto.NetworkErrorReasonFailed}.Call(page)
+		}
+	}, func(e *proto.FetchAuthRequired) {
+		creds := server
+		if e.AuthChallenge.Source == proto.FetchAuthChallengeSourceProxy {
+			creds = proxy
+		}
+
+		response := &proto.FetchAuthChallengeResponse{
+			Response: proto.FetchAuthChallengeResponseResponseCancelAuth,
+		}
+		key := string(e.AuthChallenge.Source) + " " + e.Request.URL
+		if creds != nil && attempts[key] < maxAuthAttempts {
+			attempts[key]++
+			password, _ := creds.Password()
+			response = &proto.FetchAuthChallengeResponse{
+				Response: proto.FetchAuthChallengeResponseResponseProvideCredentials,
+				Username: creds.Username(),
+				Password: password,
+			}
+		}
+		_ = proto.FetchContinueWithAuth{RequestID: e.RequestID, AuthChallengeResponse: response}.Call(page)
+	})()
+
+	return recorder, nil
+}
+
+// interceptRequest answers a paused request from the first mock that
+// matches, fails it if it is blocked, answers it from the replayed HAR, and
+// otherwise lets it through with the extra headers of its origin. In replay
+// mode nothing reaches the network: requests missing from the HAR fail as if
+// offline.
+func interceptRequest(page *rod.Page, e *proto.FetchRequestPaused, opts Options, blocked []*regexp.Regexp, replayer *harReplayer, headers *headerScope) error {
+	for i := range opts.Mocks {
+		if opts.Mocks[i].matches(e.Request) {
+			return opts.Mocks[i].fulfill(page, e.RequestID)
+		}
+	}
+
+	for _, pattern := range blocked {
+		if pattern.MatchString(e.Request.URL) {
+			return proto.FetchFailRequest{RequestID: e.RequestID, ErrorReason: proto.NetworkErrorReasonBlockedByClient}.Call(page)
+		}
+	}
+
+	if replayer != nil && strings.HasPrefix(e.Request.URL, "http") {
+		if entry := replayer.match(e.Request); entry != nil {
+			return entry.fulfill(page, e.RequestID)
+		}
+		if opts.Verbose {
+			fmt.Printf("  Not in HAR, failing: %s %s\n", e.Request.Method, e.Request.URL)
+		}
+		return proto.FetchFailRequest{RequestID: e.RequestID, ErrorReason: proto.NetworkErrorReasonInternetDisconnected}.Call(page)
+	}
+
+	return proto.FetchContinueRequest{RequestID: e.RequestID, Headers: headers.apply(e.Request)}.Call(page)
+}
+
+// headerScope holds extra headers and the one origin they're sent to
+type headerScope struct {
+	origin  string
+	headers map[string]string
+}
+
+// apply returns the headers of the request with the extra ones added, or
+// nil to leave the request alone when it goes to another origin
+func (s *headerScope) apply(req *proto.NetworkRequest) []*proto.FetchHeaderEntry {
+	if s == nil {
+		return nil
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || origin(u) != s.origin {
+		return nil
+	}
+
+	var entries []*proto.FetchHeaderEntry
+	for name, value := range req.Headers {
+		if !s.has(name) {
+			entries = append(entries, &proto.FetchHeaderEntry{Name: name, Value: value.Str()})
+		}
+	}
+	names := make([]string, 0, len(s.headers))
+	for name := range s.headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		entries = append(entries, &proto.FetchHeaderEntry{Name: name, Value: s.headers[name]})
+	}
+	return entries
+}
+
+// has reports whether an extra header replaces the named one
+func (s *headerScope) has(name string) bool {
+	for n := range s.headers {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// origin returns the scheme, host and port of a URL
+func origin(u *url.URL) string {
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host)
+}
@@ -11,12 +11,49 @@ type PageMap struct {
 
 // Element represents an interactive element on the page
 type Element struct {
-	Selector    string `json:"selector"`
-	Type        string `json:"type"` // button, input, link, select, checkbox, radio
-	Text        string `json:"text,omitempty"`
-	Placeholder string `json:"placeholder,omitempty"`
-	Name        string `json:"name,omitempty"`
-	ID          string `json:"id,omitempty"`
+	Selector    string         `json:"selector"`
+	Locator     *Locator       `json:"locator,omitempty"` // Fallback-friendly ways to find the element
+	Type        string         `json:"type"`              // button, input, link, select, checkbox, radio, file
+	Text        string         `json:"text,omitempty"`
+	Placeholder string         `json:"placeholder,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	ID          string         `json:"id,omitempty"`
+	Options     []SelectOption `json:"options,omitempty"`  // Choices of a select element
+	Accept      string         `json:"accept,omitempty"`   // Accepted file types of a file input
+	Multiple    bool           `json:"multiple,omitempty"` // File input takes several files
+	Hidden      bool           `json:"hidden,omitempty"`   // Not visible itself (e.g. behind a styled drop zone)
+	Editor      string         `json:"editor,omitempty"`   // Rich text editor kind: contenteditable, prosemirror, codemirror, monaco
+	Frame       []string       `json:"frame,omitempty"`    // Selectors of the iframes containing the element, outermost first
+
+	// Layout and state
+	Box        *Rect             `json:"box,omitempty"`      // Position and size in CSS pixels, relative to the element's frame
+	InViewport bool              `json:"inViewport"`         // At least partly on screen without scrolling
+	Label      string            `json:"label,omitempty"`    // Text of the label associated with a form control
+	Context    string            `json:"context,omitempty"`  // Nearest landmark or heading, e.g. "dialog 'New set'"
+	Disabled   bool              `json:"disabled,omitempty"` // Disabled natively or with aria-disabled
+	Readonly   bool              `json:"readonly,omitempty"` // Read-only natively or with aria-readonly
+	Required   bool              `json:"required,omitempty"` // Required natively or with aria-required
+	Expanded   *bool             `json:"expanded,omitempty"` // Set for elements that expand, e.g. menus and comboboxes
+	Checked    string            `json:"checked,omitempty"`  // "true", "false" or "mixed" for checkable elements
+	ARIA       map[string]string `json:"aria,omitempty"`     // Other aria-* states: pressed, selected, current, invalid, haspopup
+
+	// Accessibility discovery mode only
+	Role           string `json:"role,omitempty"`           // Computed ARIA role
+	AccessibleName string `json:"accessibleName,omitempty"` // Computed accessible name
+}
+
+// Rect is an element's bounding box
+type Rect struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// SelectOption represents one option of a select element
+type SelectOption struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
 }
 
 // NavItem represents a navigation link
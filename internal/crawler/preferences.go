@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Color schemes for Options.ColorScheme
+const (
+	ColorSchemeLight = "light"
+	ColorSchemeDark  = "dark"
+)
+
+// Geolocation is a fixed position reported to navigator.geolocation
+type Geolocation struct {
+	Latitude  float64
+	Longitude float64
+	Accuracy  float64 // In meters
+}
+
+// ParseGeolocation parses "latitude,longitude" or "latitude,longitude,accuracy"
+func ParseGeolocation(s string) (*Geolocation, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("invalid geolocation %q: use \"latitude,longitude[,accuracy]\"", s)
+	}
+	values := make([]float64, len(parts))
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid geolocation %q: %w", s, err)
+		}
+		values[i] = v
+	}
+
+	g := &Geolocation{Latitude: values[0], Longitude: values[1], Accuracy: 10}
+	if len(values) == 3 {
+		g.Accuracy = values[2]
+	}
+	if g.Latitude < -90 || g.Latitude > 90 || g.Longitude < -180 || g.Longitude > 180 {
+		return nil, fmt.Errorf("invalid geolocation %q: latitude must be within ±90 and longitude within ±180", s)
+	}
+	return g, nil
+}
+
+// emulatesPreferences reports whether any user preference is overridden
+func (o Options) emulatesPreferences() bool {
+	return o.ColorScheme != "" || o.ReducedMotion || o.Locale != "" || o.Timezone != "" || o.Geolocation != nil
+}
+
+// applyPreferences emulates the color scheme, motion preference, locale,
+// timezone and position of the user. The user agent override that carries
+// the locale's Accept-Language is set by applyEmulation.
+func applyPreferences(browser *rod.Browser, page *rod.Page, opts Options) error {
+	var features []*proto.EmulationMediaFeature
+	if opts.ColorScheme != "" {
+		features = append(features, &proto.EmulationMediaFeature{Name: "prefers-color-scheme", Value: opts.ColorScheme})
+	}
+	if opts.ReducedMotion {
+		features = append(features, &proto.EmulationMediaFeature{Name: "prefers-reduced-motion", Value: "reduce"})
+	}
+	if len(features) > 0 {
+		if err := (proto.EmulationSetEmulatedMedia{Features: features}).Call(page); err != nil {
+			return fmt.Errorf("failed to emulate media features: %w", err)
+		}
+	}
+
+	if opts.Locale != "" {
+		if err := (proto.EmulationSetLocaleOverride{Locale: opts.Locale}).Call(page); err != nil {
+			return fmt.Errorf("failed to set locale %s: %w", opts.Locale, err)
+		}
+	}
+
+	if opts.Timezone != "" {
+		if err := (proto.EmulationSetTimezoneOverride{TimezoneID: opts.Timezone}).Call(page); err != nil {
+			return fmt.Errorf("failed to set timezone %s: %w", opts.Timezone, err)
+		}
+	}
+
+	if g := opts.Geolocation; g != nil {
+		err := proto.BrowserGrantPermissions{
+			Permissions: []proto.BrowserPermissionType{proto.BrowserPermissionTypeGeolocation},
+		}.Call(browser)
+		if err != nil {
+			return fmt.Errorf("failed to grant geolocation: %w", err)
+		}
+		err = proto.EmulationSetGeolocationOverride{
+			Latitude:  &g.Latitude,
+			Longitude: &g.Longitude,
+			Accuracy:  &g.Accuracy,
+		}.Call(page)
+		if err != nil {
+			return fmt.Errorf("failed to set geolocation: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// ShadowSeparator joins the selectors of shadow hosts and of the element
+// inside them, e.g. "app-shell >>> settings-panel >>> button.save"
+const ShadowSeparator = " >>> "
+
+// DeepQueryJS defines JS helpers that look inside open shadow roots. It is
+// meant to be placed at the top of a function body passed to Eval:
+//
+//	allRoots()          the document and every open shadow root below it
+//	deepQueryAll(sel)   querySelectorAll across all of those roots
+//	deepQuery(sel)      resolves a ShadowSeparator chain to one element
+const DeepQueryJS = `
+	function allRoots(root = document) {
+		const roots = [root];
+		root.querySelectorAll('*').forEach(n => {
+			if (n.shadowRoot) roots.push(...allRoots(n.shadowRoot));
+		});
+		return roots;
+	}
+	function deepQueryAll(selector) {
+		return allRoots().flatMap(root => Array.from(root.querySelectorAll(selector)));
+	}
+	function deepQuery(selector) {
+		const parts = selector.split('>>>').map(s => s.trim());
+		let scope = document;
+		for (let i = 0; i < parts.length; i++) {
+			const el = scope.querySelector(parts[i]);
+			if (!el || i === parts.length - 1) return el;
+			if (!el.shadowRoot) return null;
+			scope = el.shadowRoot;
+		}
+		return null;
+	}
+`
+
+// FindElement resolves a selector from the page map. Selectors that reach
+// into shadow roots are walked host by host, and role locators are looked
+// up in the accessibility tree.
+func FindElement(page *rod.Page, selector string) (*rod.Element, error) {
+	if strings.HasPrefix(selector, "role=") {
+		return findByRole(page, selector)
+	}
+	return queryElement(page.Sleeper(clockSleeper(page)), selector)
+}
+
+// queryElement resolves a CSS or shadow-piercing selector, waiting with the
+// page's sleeper
+func queryElement(page *rod.Page, selector string) (*rod.Element, error) {
+	if !strings.Contains(selector, ">>>") {
+		return page.Element(selector)
+	}
+	return page.ElementByJS(rod.Eval(`(selector) => {`+DeepQueryJS+`
+		return deepQuery(selector);
+	}`, selector))
+}
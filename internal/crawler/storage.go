@@ -0,0 +1,220 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// StorageState is a saved login: the browser's cookies plus localStorage
+// and sessionStorage per origin
+type StorageState struct {
+	Cookies []Cookie      `json:"cookies"`
+	Origins []OriginState `json:"origins"`
+}
+
+// Cookie is one browser cookie
+type Cookie struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires"` // Unix time in seconds, -1 for session cookies
+	HTTPOnly bool    `json:"httpOnly"`
+	Secure   bool    `json:"secure"`
+	SameSite string  `json:"sameSite,omitempty"` // Strict, Lax or None
+}
+
+// OriginState holds the web storage of one origin, e.g. https://app.example.com
+type OriginState struct {
+	Origin         string         `json:"origin"`
+	LocalStorage   []StorageEntry `json:"localStorage,omitempty"`
+	SessionStorage []StorageEntry `json:"sessionStorage,omitempty"`
+}
+
+// StorageEntry is one key of localStorage or sessionStorage
+type StorageEntry struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// LoadStorageState reads a storage state file
+func LoadStorageState(path string) (*StorageState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage state: %w", err)
+	}
+	var state StorageState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse storage state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Save writes the storage state to a file only the current user can read,
+// since it holds session credentials
+func (s *StorageState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal storage state: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write storage state: %w", err)
+	}
+	return nil
+}
+
+// seedStorageJS fills in web storage for the origins of a storage state the
+// first time a page of each origin loads in the tab. It is completed with
+// the origins as a JSON array.
+const seedStorageJS = `(origins => {
+	const marker = '__demogif_storage_seeded';
+	const state = origins.find(o => o.origin === location.origin);
+	if (!state) return;
+	try {
+		if (sessionStorage.getItem(marker)) return;
+		(state.localStorage || []).forEach(e => localStorage.setItem(e.name, e.value));
+		(state.sessionStorage || []).forEach(e => sessionStorage.setItem(e.name, e.value));
+		sessionStorage.setItem(marker, '1');
+	} catch (e) {
+		// Storage is disabled for this document, e.g. a sandboxed frame
+	}
+})`
+
+// applyStorageState loads cookies into the browser and web storage into the
+// page, before it navigates anywhere
+func applyStorageState(browser *rod.Browser, page *rod.Page, state *StorageState) error {
+	cookies := make([]*proto.NetworkCookieParam, len(state.Cookies))
+	for i, c := range state.Cookies {
+		cookies[i] = &proto.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+			SameSite: proto.NetworkCookieSameSite(c.SameSite),
+		}
+		if c.Expires > 0 {
+			cookies[i].Expires = proto.TimeSinceEpoch(c.Expires)
+		}
+	}
+	if len(cookies) > 0 {
+		if err := browser.SetCookies(cookies); err != nil {
+			return fmt.Errorf("failed to set cookies: %w", err)
+		}
+	}
+
+	if len(state.Origins) > 0 {
+		origins, err := json.Marshal(state.Origins)
+		if err != nil {
+			return fmt.Errorf("failed to marshal storage state: %w", err)
+		}
+		if _, err := page.EvalOnNewDocument(seedStorageJS + "(" + string(origins) + ");"); err != nil {
+			return fmt.Errorf("failed to load web storage: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// readWebStorageJS returns the origin and web storage of a page
+const readWebStorageJS = `() => {
+	const entries = s => Object.keys(s).map(name => ({ name, value: s.getItem(name) }));
+	return {
+		origin: location.origin,
+		localStorage: entries(localStorage),
+		sessionStorage: entries(sessionStorage)
+	};
+}`
+
+// OpenForLogin opens a visible browser at url so the user can log in by
+// hand before the session is saved with StorageState. The page gets the
+// viewport of the options, so sites that store layout or device specific
+// state see the size the recording will use.
+func OpenForLogin(url string, opts Options) (*Browser, error) {
+	path, _ := launcher.LookPath()
+	l := siteIsolation(launcher.New().Bin(path).Headless(false), true)
+	if opts.Width > 0 && opts.Height > 0 {
+		l = l.Set("window-size", fmt.Sprintf("%d,%d", opts.Width, opts.Height))
+	}
+	u, err := l.Launch()
+	if err != nil {
+		return nil, fmt.Errorf("failed to launch browser: %w", err)
+	}
+
+	browser := rod.New().ControlURL(u)
+	if err := browser.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to browser: %w", err)
+	}
+
+	page, err := browser.Page(proto.TargetCreateTarget{URL: url})
+	if err != nil {
+		browser.Close()
+		return nil, fmt.Errorf("failed to open page: %w", err)
+	}
+	if opts.Width > 0 && opts.Height > 0 {
+		if err := applyEmulation(page, opts); err != nil {
+			browser.Close()
+			return nil, err
+		}
+	}
+
+	return &Browser{browser: browser, page: page, opts: opts}, nil
+}
+
+// StorageState exports the cookies of the browser and the web storage of
+// every origin open in its tabs
+func (b *Browser) StorageState() (*StorageState, error) {
+	cookies, err := b.browser.GetCookies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cookies: %w", err)
+	}
+
+	state := &StorageState{Cookies: []Cookie{}, Origins: []OriginState{}}
+	for _, c := range cookies {
+		expires := float64(c.Expires)
+		if c.Session {
+			expires = -1
+		}
+		state.Cookies = append(state.Cookies, Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  expires,
+			HTTPOnly: c.HTTPOnly,
+			Secure:   c.Secure,
+			SameSite: string(c.SameSite),
+		})
+	}
+
+	pages, err := b.browser.Pages()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+	seen := map[string]bool{}
+	for _, page := range pages {
+		res, err := page.Eval(readWebStorageJS)
+		if err != nil {
+			continue // e.g. a crashed tab or a chrome:// page
+		}
+		var origin OriginState
+		if err := res.Value.Unmarshal(&origin); err != nil {
+			continue
+		}
+		if origin.Origin == "" || origin.Origin == "null" || seen[origin.Origin] {
+			continue
+		}
+		seen[origin.Origin] = true
+		if len(origin.LocalStorage) > 0 || len(origin.SessionStorage) > 0 {
+			state.Origins = append(state.Origins, origin)
+		}
+	}
+
+	return state, nil
+}
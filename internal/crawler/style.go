@@ -0,0 +1,40 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-rod/rod"
+)
+
+// addStyleJS adds a stylesheet to the document as soon as it has a root
+// element, which scripts evaluated on a new document run before. It is
+// completed with the CSS as a JSON string.
+const addStyleJS = `(css => {
+	const style = document.createElement('style');
+	style.dataset.demogif = '';
+	style.textContent = css;
+	const add = () => (document.head || document.documentElement).appendChild(style);
+	if (document.documentElement) {
+		add();
+	} else {
+		new MutationObserver((_, observer) => {
+			if (document.documentElement) {
+				observer.disconnect();
+				add();
+			}
+		}).observe(document, { childList: true });
+	}
+})`
+
+// injectStyle adds css to every document the page loads
+func injectStyle(page *rod.Page, css string) error {
+	arg, err := json.Marshal(css)
+	if err != nil {
+		return fmt.Errorf("failed to marshal CSS: %w", err)
+	}
+	if _, err := page.EvalOnNewDocument(addStyleJS + "(" + string(arg) + ");"); err != nil {
+		return fmt.Errorf("failed to inject CSS: %w", err)
+	}
+	return nil
+}
@@ -1,23 +1,49 @@
 package executor
 
+import "github.com/v0xg/demogif/internal/crawler"
+
 // Action represents a single browser automation action
 type Action struct {
-	Type       string `json:"action"`             // click, type, scroll, hover, wait, navigate
-	Selector   string `json:"selector,omitempty"` // CSS selector for the target element
-	Text       string `json:"text,omitempty"`     // Text to type (for type action)
-	X          int    `json:"x,omitempty"`        // X coordinate (for scroll)
-	Y          int    `json:"y,omitempty"`        // Y coordinate (for scroll)
-	URL        string `json:"url,omitempty"`      // URL for navigate action
-	Duration   int    `json:"wait,omitempty"`     // Wait duration in ms after action
-	Checkpoint bool   `json:"checkpoint,omitempty"` // If true, re-crawl page after this action
+	Type          string           `json:"action"`                  // click, type, press, select, check, uncheck, upload, drag, scroll, hover, wait, waitFor, assert, navigate
+	Selector      string           `json:"selector,omitempty"`      // CSS selector for the target element
+	Locator       *crawler.Locator `json:"locator,omitempty"`       // Preferred ways to find the target element, tried before Selector
+	Target        string           `json:"target,omitempty"`        // CSS selector of the drop target (for drag action)
+	TargetLocator *crawler.Locator `json:"targetLocator,omitempty"` // Preferred ways to find the drop target, tried before Target
+	Frame         []string         `json:"frame,omitempty"`         // Iframe selectors leading to the element, from the page map
+	Text          string           `json:"text,omitempty"`          // Text to type (for type action)
+	WPM           int              `json:"wpm,omitempty"`           // Typing speed in words per minute (for type action)
+	Typing        string           `json:"typing,omitempty"`        // Typing mode: steady, human, paste (for type action)
+	Append        bool             `json:"append,omitempty"`        // Add to the existing text instead of replacing it (for type action)
+	Value         *string          `json:"value,omitempty"`         // Option value or visible label (for select), expected input value, possibly empty (for assert)
+	Files         []string         `json:"files,omitempty"`         // Fixture file paths to set on a file input (for upload action)
+	Key           string           `json:"key,omitempty"`           // Key or chord such as "Enter" or "Meta+K" (for press action)
+	X             int              `json:"x,omitempty"`             // X coordinate (for scroll, or drag offset)
+	Y             int              `json:"y,omitempty"`             // Y coordinate (for scroll, or drag offset)
+	URL           string           `json:"url,omitempty"`           // URL for navigate action
+	Duration      int              `json:"wait,omitempty"`          // Wait duration in ms after action
+	WaitFor       *WaitCondition   `json:"waitFor,omitempty"`       // Condition to wait for after the action (or for waitFor action)
+	Checkpoint    bool             `json:"checkpoint,omitempty"`    // If true, re-crawl page after this action
+}
+
+// WaitCondition describes what to wait for before moving on. All the set
+// fields must hold at the same time.
+type WaitCondition struct {
+	Selector    string  `json:"selector,omitempty"`    // Element that must be visible
+	Hidden      bool    `json:"hidden,omitempty"`      // Wait for Selector to disappear instead
+	Value       *string `json:"value,omitempty"`       // Value the input at Selector must have, possibly empty
+	Text        string  `json:"text,omitempty"`        // Text that must be visible on the page
+	URL         string  `json:"url,omitempty"`         // Regular expression (or substring) the URL must match
+	NetworkIdle bool    `json:"networkIdle,omitempty"` // No requests in flight for 500ms
+	Timeout     int     `json:"timeout,omitempty"`     // Give up after this many ms (default 10000)
 }
 
 // CursorPosition represents the cursor state at a point in time
 type CursorPosition struct {
-	X      int
-	Y      int
-	State  CursorState
-	Click  bool // Whether a click happened at this position
+	X     int
+	Y     int
+	State CursorState
+	Click bool   // Whether a click happened at this position
+	Keys  string // Key chord being pressed, shown as a keystroke caption
 }
 
 // CursorState represents the visual state of the cursor
@@ -27,4 +53,5 @@ const (
 	CursorDefault CursorState = iota
 	CursorPointer
 	CursorText
+	CursorGrabbing // Mouse button held down while dragging
 )
@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// executeDragAnimated presses on the source element, moves along a gentle
+// arc to the target element (or by the x/y offset) and releases
+func executeDragAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
+	source, err := findTarget(page, action, opts)
+	if err != nil {
+		return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Selector, err)
+	}
+
+	fromX, fromY, err := getElementCenter(source)
+	if err != nil {
+		return nil, currentCursor, err
+	}
+
+	toX, toY := fromX+action.X, fromY+action.Y
+	if action.Target != "" {
+		target, err := findTarget(page, Action{Selector: action.Target, Locator: action.TargetLocator}, opts)
+		if err != nil {
+			return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Target, err)
+		}
+		toX, toY, err = getElementCenter(target)
+		if err != nil {
+			return nil, currentCursor, err
+		}
+	} else if action.X == 0 && action.Y == 0 {
+		return nil, currentCursor, fmt.Errorf("drag needs a target selector or an x/y offset")
+	}
+
+	frames := animateCursorMove(page, currentCursor, fromX, fromY, CursorPointer, opts, frameInterval)
+
+	// Native HTML5 drag and drop doesn't run off synthetic mouse events, so
+	// intercept it and replay it with drag events. Libraries built on pointer
+	// events never start a native drag and just follow the mouse.
+	if err := (proto.InputSetInterceptDrags{Enabled: true}).Call(page); err != nil {
+		return nil, currentCursor, err
+	}
+	defer func() { _ = proto.InputSetInterceptDrags{Enabled: false}.Call(page) }()
+
+	ctx, cancel := context.WithCancel(page.GetContext())
+	defer cancel()
+	intercepted := make(chan *proto.InputDragData, 1)
+	go page.Context(ctx).EachEvent(func(e *proto.InputDragIntercepted) bool {
+		intercepted <- e.Data
+		return true
+	})()
+
+	// Pick up
+	if err := page.Mouse.Down(proto.InputMouseButtonLeft, 1); err != nil {
+		return nil, currentCursor, err
+	}
+	grabbing := CursorPosition{X: fromX, Y: fromY, State: CursorGrabbing}
+	frames = append(frames, captureFrames(page, grabbing, opts.FPS/5, frameInterval)...)
+
+	// Move along a slight arc, bowed perpendicular to the direction of travel
+	steps := opts.FPS
+	if steps < 10 {
+		steps = 10
+	}
+	dx, dy := float64(toX-fromX), float64(toY-fromY)
+	bow := math.Min(math.Hypot(dx, dy)*0.1, 40)
+	var native *proto.InputDragData
+	for i := 1; i <= steps; i++ {
+		t := easeInOutQuad(float64(i) / float64(steps))
+		offset := bow * math.Sin(t*math.Pi)
+		length := math.Max(math.Hypot(dx, dy), 1)
+		x := float64(fromX) + t*dx - offset*dy/length
+		y := float64(fromY) + t*dy + offset*dx/length
+
+		if err := page.Mouse.MoveTo(proto.Point{X: x, Y: y}); err != nil {
+			_ = page.Mouse.Up(proto.InputMouseButtonLeft, 1)
+			return nil, currentCursor, err
+		}
+
+		select {
+		case data := <-intercepted:
+			native = data
+			_ = dispatchDrag(page, proto.InputDispatchDragEventTypeDragEnter, x, y, native)
+		default:
+		}
+		if native != nil {
+			_ = dispatchDrag(page, proto.InputDispatchDragEventTypeDragOver, x, y, native)
+		}
+
+		frame, err := captureFrame(page, frameInterval)
+		if err == nil {
+			cursor := CursorPosition{X: int(x), Y: int(y), State: CursorGrabbing}
+			frames = append(frames, FrameData{Image: frame, Cursor: cursor})
+		}
+		time.Sleep(frameInterval / 2)
+	}
+
+	// Drop
+	if native != nil {
+		if err := dispatchDrag(page, proto.InputDispatchDragEventTypeDrop, float64(toX), float64(toY), native); err != nil {
+			_ = page.Mouse.Up(proto.InputMouseButtonLeft, 1)
+			return nil, currentCursor, fmt.Errorf("drop failed: %w", err)
+		}
+	}
+	if err := page.Mouse.Up(proto.InputMouseButtonLeft, 1); err != nil {
+		return nil, currentCursor, err
+	}
+
+	cursor := CursorPosition{X: toX, Y: toY, State: CursorPointer}
+	frames = append(frames, captureFrames(page, cursor, opts.FPS/3, frameInterval)...)
+
+	return frames, cursor, nil
+}
+
+// dispatchDrag sends one native drag event at the given viewport position
+func dispatchDrag(page *rod.Page, eventType proto.InputDispatchDragEventType, x, y float64, data *proto.InputDragData) error {
+	return proto.InputDispatchDragEvent{
+		Type: eventType,
+		X:    x,
+		Y:    y,
+		Data: data,
+	}.Call(page)
+}
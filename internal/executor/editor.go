@@ -0,0 +1,107 @@
+package executor
+
+import (
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+)
+
+// editableTargetJS finds the element that takes keyboard input for a text
+// target: the element itself for inputs and contenteditable elements, or the
+// editable surface (CodeMirror 6) or hidden textarea (Monaco, CodeMirror 5)
+// inside an editor root
+const editableTargetJS = `() => {
+	if (this instanceof HTMLInputElement || this instanceof HTMLTextAreaElement || this.isContentEditable) {
+		return this;
+	}
+	return this.querySelector('[contenteditable="true"], [contenteditable=""], .cm-content, textarea') || this;
+}`
+
+// textTargetKindJS tells native fields, contenteditable elements and the
+// hidden textareas that code editors read keystrokes from apart
+const textTargetKindJS = `() => {
+	if (this.isContentEditable) return 'contenteditable';
+	if (this instanceof HTMLTextAreaElement && this.closest('.monaco-editor, .CodeMirror')) return 'code';
+	if (this instanceof HTMLInputElement || this instanceof HTMLTextAreaElement) return 'native';
+	return 'code';
+}`
+
+// caretToEndJS moves the caret of a native field to the end of its value
+const caretToEndJS = `() => {
+	const n = this.value.length;
+	try { this.setSelectionRange(n, n); } catch (e) {} // Not supported by e.g. email inputs
+}`
+
+// selectContentsJS selects everything in a contenteditable element, or puts
+// the caret at its end to append. It reports whether there is anything
+// selected to delete.
+const selectContentsJS = `(appendText) => {
+	const range = document.createRange();
+	range.selectNodeContents(this);
+	if (appendText) range.collapse(false);
+	const selection = window.getSelection();
+	selection.removeAllRanges();
+	selection.addRange(range);
+	return !appendText && this.textContent.length > 0;
+}`
+
+// prepareTextTarget focuses a text field or editor and places the caret:
+// at the end when appending, otherwise over the existing content so that
+// typing replaces it. It returns the element that receives the keystrokes.
+func prepareTextTarget(page *rod.Page, el *rod.Element, appendText bool) (*rod.Element, error) {
+	target, err := el.ElementByJS(rod.Eval(editableTargetJS))
+	if err != nil {
+		return nil, err
+	}
+	if err := target.Focus(); err != nil {
+		return nil, err
+	}
+
+	kind, err := target.Eval(textTargetKindJS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind.Value.String() {
+	case "native":
+		if appendText {
+			_, err = target.Eval(caretToEndJS)
+			return target, err
+		}
+		return target, target.SelectAllText()
+
+	case "contenteditable":
+		// Editors like ProseMirror follow the DOM selection, and deleting
+		// through a key event lets them update their own document model
+		res, err := target.Eval(selectContentsJS, appendText)
+		if err != nil {
+			return nil, err
+		}
+		if res.Value.Bool() {
+			return target, page.Keyboard.Type(input.Backspace)
+		}
+		return target, nil
+
+	default:
+		// Code editors keep their text out of the DOM, so use their shortcuts
+		var keys string
+		switch {
+		case !appendText:
+			keys = "Mod+A"
+		case input.IsMac:
+			keys = "Cmd+ArrowDown"
+		default:
+			keys = "Ctrl+End"
+		}
+		c, err := parseChord(keys)
+		if err != nil {
+			return nil, err
+		}
+		if err := pressChord(page, c); err != nil {
+			return nil, err
+		}
+		if !appendText {
+			return target, page.Keyboard.Type(input.Backspace)
+		}
+		return target, nil
+	}
+}
@@ -5,19 +5,22 @@ import (
 	"fmt"
 	"image"
 	_ "image/png"
+	"math/rand/v2"
 	"time"
 
 	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/input"
 	"github.com/go-rod/rod/lib/proto"
 	"github.com/v0xg/demogif/internal/crawler"
 )
 
 // Options configures execution behavior
 type Options struct {
-	FPS       int
-	BaseDelay int // Base delay between actions in ms
-	Verbose   bool
+	FPS        int
+	BaseDelay  int // Base delay between actions in ms
+	Verbose    bool
+	FixtureDir string     // Directory that relative upload paths are resolved against
+	Touch      bool       // Press elements with touch taps instead of mouse clicks
+	Rand       *rand.Rand // Seeded source for typing jitter and typos (nil for an unseeded one)
 }
 
 // FrameData holds a captured frame with its cursor state
@@ -32,7 +35,15 @@ type ExecuteResult struct {
 	CursorPositions []CursorPosition
 	LastCursor      CursorPosition
 	HitCheckpoint   bool
-	CheckpointIndex int // Index of the checkpoint action that was hit (-1 if none)
+	CheckpointIndex int             // Index of the checkpoint action that was hit (-1 if none)
+	Failures        []ActionFailure // Actions that failed, assertions that didn't hold, waits that timed out
+}
+
+// ActionFailure records an action of a batch that didn't work
+type ActionFailure struct {
+	Index  int // Index of the action within the batch
+	Action Action
+	Err    error
 }
 
 // ExecuteBatch runs actions until a checkpoint is hit or all actions complete
@@ -45,7 +56,7 @@ func ExecuteBatch(browser *crawler.Browser, actions []Action, opts Options, star
 	frameInterval := time.Duration(1000/opts.FPS) * time.Millisecond
 
 	// Current cursor position
-	currentCursor := CursorPosition{X: 640, Y: 360, State: CursorDefault}
+	currentCursor := initialCursor(browser)
 	if startCursor != nil {
 		currentCursor = *startCursor
 	}
@@ -59,12 +70,21 @@ func ExecuteBatch(browser *crawler.Browser, actions []Action, opts Options, star
 			fmt.Printf("  [%d/%d] %s %s", i+1, len(actions), action.Type, action.Selector)
 		}
 
+		// Requests are tracked from before the action, so a network idle
+		// wait after it also sees the ones the action started
+		var requests *requestTracker
+		if action.WaitFor != nil && action.WaitFor.NetworkIdle && action.Type != "waitFor" {
+			requests = trackRequests(page)
+		}
+
 		// Execute the action with animation
 		newFrames, newCursor, err := executeActionAnimated(page, action, currentCursor, opts, frameInterval)
 		if err != nil {
+			requests.stop()
 			if opts.Verbose {
 				fmt.Printf(" ✗ (%v)\n", err)
 			}
+			result.Failures = append(result.Failures, ActionFailure{Index: i, Action: action, Err: err})
 			continue
 		}
 
@@ -79,13 +99,31 @@ func ExecuteBatch(browser *crawler.Browser, actions []Action, opts Options, star
 		frameData = append(frameData, newFrames...)
 		currentCursor = newCursor
 
-		// Post-action wait with frame capture
-		waitTime := action.Duration
-		if waitTime == 0 {
-			waitTime = opts.BaseDelay
+		// Post-action wait with frame capture: until the action's condition
+		// holds if it has one, otherwise for a fixed time. Waits and
+		// assertions have done their waiting already and only wait their own
+		// duration.
+		if action.WaitFor != nil && action.Type != "waitFor" {
+			waitFrames, err := waitForCondition(page, *action.WaitFor, requests, currentCursor, frameInterval)
+			requests.stop()
+			frameData = append(frameData, waitFrames...)
+			if err != nil {
+				if opts.Verbose {
+					fmt.Printf("    ⚠ %v\n", err)
+				}
+				result.Failures = append(result.Failures, ActionFailure{Index: i, Action: action, Err: err})
+			}
+			if action.Duration > 0 {
+				frameData = append(frameData, captureWaitFrames(page, currentCursor, action.Duration, frameInterval)...)
+			}
+		} else if !waitsItself(action) || action.Duration > 0 {
+			waitTime := action.Duration
+			if waitTime == 0 {
+				waitTime = opts.BaseDelay
+			}
+			waitFrames := captureWaitFrames(page, currentCursor, waitTime, frameInterval)
+			frameData = append(frameData, waitFrames...)
 		}
-		waitFrames := captureWaitFrames(page, currentCursor, waitTime, frameInterval)
-		frameData = append(frameData, waitFrames...)
 
 		// If this was a checkpoint, stop and signal re-crawl needed
 		if action.Checkpoint {
@@ -107,6 +145,12 @@ func ExecuteBatch(browser *crawler.Browser, actions []Action, opts Options, star
 	return result, nil
 }
 
+// waitsItself reports whether an action spends its time waiting for a
+// condition, so it needs no pause after it
+func waitsItself(action Action) bool {
+	return action.Type == "waitFor" || action.Type == "assert"
+}
+
 // Execute runs the action sequence and captures frames with animation
 // Deprecated: Use ExecuteBatch for checkpoint support
 func Execute(browser *crawler.Browser, actions []Action, opts Options) ([]image.Image, []CursorPosition, error) {
@@ -117,12 +161,12 @@ func Execute(browser *crawler.Browser, actions []Action, opts Options) ([]image.
 	frameInterval := time.Duration(1000/opts.FPS) * time.Millisecond
 
 	// Current cursor position (starts at center of screen)
-	currentCursor := CursorPosition{X: 640, Y: 360, State: CursorDefault}
+	currentCursor := initialCursor(browser)
 
 	// Capture initial frames (hold for ~1 second)
 	initialFrames := opts.FPS // 1 second worth of frames
 	for i := 0; i < initialFrames; i++ {
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to capture initial frame: %w", err)
 		}
@@ -162,7 +206,7 @@ func Execute(browser *crawler.Browser, actions []Action, opts Options) ([]image.
 	// Final hold frames (~1 second)
 	finalFrames := opts.FPS
 	for i := 0; i < finalFrames; i++ {
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err == nil {
 			frameData = append(frameData, FrameData{Image: frame, Cursor: currentCursor})
 		}
@@ -181,11 +225,37 @@ func Execute(browser *crawler.Browser, actions []Action, opts Options) ([]image.
 
 // executeActionAnimated executes an action with animated frames
 func executeActionAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
+	// Actions on elements inside iframes run against the frame. Cursor
+	// positions all come from element shapes, which the protocol reports in
+	// top-level viewport coordinates for in-process frames (the only kind
+	// EnterFrame enters); a position measured by a script in the frame
+	// would be frame-relative instead.
+	if len(action.Frame) > 0 {
+		framePage, err := crawler.EnterFrame(page, action.Frame)
+		if err != nil {
+			return nil, currentCursor, err
+		}
+		page = framePage
+	}
+
 	switch action.Type {
 	case "click":
 		return executeClickAnimated(page, action, currentCursor, opts, frameInterval)
 	case "type":
 		return executeTypeAnimated(page, action, currentCursor, opts, frameInterval)
+	case "select":
+		if action.Value == nil {
+			return nil, currentCursor, fmt.Errorf("select action needs a value")
+		}
+		return executeSelectAnimated(page, action, currentCursor, opts, frameInterval)
+	case "check", "uncheck":
+		return executeCheckAnimated(page, action, currentCursor, opts, frameInterval)
+	case "upload":
+		return executeUploadAnimated(page, action, currentCursor, opts, frameInterval)
+	case "drag":
+		return executeDragAnimated(page, action, currentCursor, opts, frameInterval)
+	case "press":
+		return executePressAnimated(page, action, currentCursor, opts, frameInterval)
 	case "scroll":
 		return executeScrollAnimated(page, action, currentCursor, opts, frameInterval)
 	case "hover":
@@ -193,10 +263,28 @@ func executeActionAnimated(page *rod.Page, action Action, currentCursor CursorPo
 	case "wait":
 		frames := captureWaitFrames(page, currentCursor, action.Duration, frameInterval)
 		return frames, currentCursor, nil
+	case "assert":
+		if action.Selector == "" && action.Text == "" && action.URL == "" {
+			return nil, currentCursor, fmt.Errorf("assert action needs a selector, text or url")
+		}
+		frames, err := waitForCondition(page, assertCondition(action), nil, currentCursor, frameInterval)
+		if err != nil {
+			return nil, currentCursor, fmt.Errorf("assertion failed: %w", err)
+		}
+		return frames, currentCursor, nil
+	case "waitFor":
+		if action.WaitFor == nil {
+			return nil, currentCursor, fmt.Errorf("waitFor action needs a waitFor condition")
+		}
+		frames, err := waitForCondition(page, *action.WaitFor, nil, currentCursor, frameInterval)
+		if err != nil {
+			return nil, currentCursor, err
+		}
+		return frames, currentCursor, nil
 	case "navigate":
 		page.MustNavigate(action.URL)
 		page.MustWaitLoad()
-		frame, _ := captureFrame(page)
+		frame, _ := captureFrame(page, frameInterval)
 		return []FrameData{{Image: frame, Cursor: currentCursor}}, currentCursor, nil
 	default:
 		return nil, currentCursor, fmt.Errorf("unknown action type: %s", action.Type)
@@ -205,9 +293,9 @@ func executeActionAnimated(page *rod.Page, action Action, currentCursor CursorPo
 
 // executeClickAnimated performs a click with cursor movement animation
 func executeClickAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
-	el, err := page.Element(action.Selector)
+	el, err := findTarget(page, action, opts)
 	if err != nil {
-		return nil, currentCursor, fmt.Errorf("element not found: %s", action.Selector)
+		return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Selector, err)
 	}
 
 	x, y, err := getElementCenter(el)
@@ -233,7 +321,7 @@ func executeClickAnimated(page *rod.Page, action Action, currentCursor CursorPos
 		// Move actual mouse
 		page.Mouse.MustMoveTo(float64(interpX), float64(interpY))
 
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			continue
 		}
@@ -245,7 +333,9 @@ func executeClickAnimated(page *rod.Page, action Action, currentCursor CursorPos
 	}
 
 	// Perform actual click
-	el.MustClick()
+	if err := pressElement(el, opts); err != nil {
+		return frames, currentCursor, err
+	}
 
 	// Capture click frames (show click indicator for ~0.3 seconds)
 	clickFrames := opts.FPS / 3
@@ -253,7 +343,7 @@ func executeClickAnimated(page *rod.Page, action Action, currentCursor CursorPos
 		clickFrames = 3
 	}
 	for i := 0; i < clickFrames; i++ {
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			continue
 		}
@@ -267,9 +357,9 @@ func executeClickAnimated(page *rod.Page, action Action, currentCursor CursorPos
 
 // executeTypeAnimated performs typing with character-by-character animation
 func executeTypeAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
-	el, err := page.Element(action.Selector)
+	el, err := findTarget(page, action, opts)
 	if err != nil {
-		return nil, currentCursor, fmt.Errorf("element not found: %s", action.Selector)
+		return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Selector, err)
 	}
 
 	x, y, err := getElementCenter(el)
@@ -294,7 +384,7 @@ func executeTypeAnimated(page *rod.Page, action Action, currentCursor CursorPosi
 
 		page.Mouse.MustMoveTo(float64(interpX), float64(interpY))
 
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			continue
 		}
@@ -306,59 +396,102 @@ func executeTypeAnimated(page *rod.Page, action Action, currentCursor CursorPosi
 	}
 
 	// Click to focus
-	el.MustClick()
+	if err := pressElement(el, opts); err != nil {
+		return frames, currentCursor, err
+	}
 
-	// Clear existing text
-	el.MustSelectAllText()
+	// Place the caret, clearing existing text unless appending
+	target, err := prepareTextTarget(page, el, action.Append)
+	if err != nil {
+		return nil, currentCursor, fmt.Errorf("focus failed: %w", err)
+	}
 
 	// Capture frame after focus
-	frame, _ := captureFrame(page)
+	frame, _ := captureFrame(page, frameInterval)
 	frames = append(frames, FrameData{
 		Image:  frame,
 		Cursor: CursorPosition{X: x, Y: y, State: CursorText},
 	})
 
-	// Type character by character
-	text := action.Text
-	typingDelay := 50 * time.Millisecond // 50ms between characters
-	frameEvery := 2                       // Capture frame every N characters
-
-	for i, char := range text {
-		// Type the character
-		page.Keyboard.MustType(input.Key(char))
-
-		// Capture frame every few characters
-		if i%frameEvery == 0 || i == len(text)-1 {
-			time.Sleep(typingDelay)
-			frame, err := captureFrame(page)
-			if err != nil {
-				continue
-			}
-			frames = append(frames, FrameData{
-				Image:  frame,
-				Cursor: CursorPosition{X: x, Y: y, State: CursorText},
-			})
-		} else {
-			time.Sleep(typingDelay / 2)
+	// Type the text, or paste it in one go
+	cursor := CursorPosition{X: x, Y: y, State: CursorText}
+	holdFrames := opts.FPS / 4
+	if action.Typing == TypingPaste {
+		if err := pasteText(page, target, action.Text); err != nil {
+			return nil, currentCursor, fmt.Errorf("paste failed: %w", err)
+		}
+		holdFrames = opts.FPS / 2 // Long enough to show the paste highlight
+	} else {
+		typedFrames, err := typeText(page, action.Text, newTypingModel(action, opts.Rand), cursor, frameInterval)
+		if err != nil {
+			return nil, currentCursor, fmt.Errorf("typing failed: %w", err)
 		}
+		frames = append(frames, typedFrames...)
 	}
 
 	// Hold on completed text for a moment
-	for i := 0; i < opts.FPS/4; i++ {
-		frame, err := captureFrame(page)
+	for i := 0; i < holdFrames; i++ {
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			continue
 		}
-		frames = append(frames, FrameData{
-			Image:  frame,
-			Cursor: CursorPosition{X: x, Y: y, State: CursorText},
-		})
+		frames = append(frames, FrameData{Image: frame, Cursor: cursor})
 		time.Sleep(frameInterval)
 	}
 
 	return frames, CursorPosition{X: x, Y: y, State: CursorText}, nil
 }
 
+// executePressAnimated presses a key or chord, showing it as a keystroke caption
+func executePressAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
+	c, err := parseChord(action.Key)
+	if err != nil {
+		return nil, currentCursor, err
+	}
+
+	// Focus the target first so the key goes to the right element
+	if action.Selector != "" {
+		el, err := findTarget(page, action, opts)
+		if err != nil {
+			return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Selector, err)
+		}
+		if err := el.Focus(); err != nil {
+			return nil, currentCursor, err
+		}
+	}
+
+	var frames []FrameData
+	cursor := currentCursor
+	cursor.Click = false
+	cursor.Keys = c.label
+
+	// Show the caption briefly before the key takes effect
+	for i := 0; i < opts.FPS/5; i++ {
+		frame, err := captureFrame(page, frameInterval)
+		if err != nil {
+			continue
+		}
+		frames = append(frames, FrameData{Image: frame, Cursor: cursor})
+		time.Sleep(frameInterval)
+	}
+
+	if err := pressChord(page, c); err != nil {
+		return nil, currentCursor, fmt.Errorf("key press failed: %w", err)
+	}
+
+	// Keep the caption up while the page reacts
+	for i := 0; i < opts.FPS/2; i++ {
+		frame, err := captureFrame(page, frameInterval)
+		if err != nil {
+			continue
+		}
+		frames = append(frames, FrameData{Image: frame, Cursor: cursor})
+		time.Sleep(frameInterval)
+	}
+
+	return frames, currentCursor, nil
+}
+
 // executeScrollAnimated performs scroll with animation
 func executeScrollAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
 	var frames []FrameData
@@ -371,7 +504,7 @@ func executeScrollAnimated(page *rod.Page, action Action, currentCursor CursorPo
 		page.Mouse.MustScroll(stepX, stepY)
 		time.Sleep(frameInterval)
 
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			continue
 		}
@@ -383,9 +516,9 @@ func executeScrollAnimated(page *rod.Page, action Action, currentCursor CursorPo
 
 // executeHoverAnimated performs hover with cursor movement animation
 func executeHoverAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
-	el, err := page.Element(action.Selector)
+	el, err := findTarget(page, action, opts)
 	if err != nil {
-		return nil, currentCursor, fmt.Errorf("element not found: %s", action.Selector)
+		return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Selector, err)
 	}
 
 	x, y, err := getElementCenter(el)
@@ -406,7 +539,7 @@ func executeHoverAnimated(page *rod.Page, action Action, currentCursor CursorPos
 
 		page.Mouse.MustMoveTo(float64(interpX), float64(interpY))
 
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			continue
 		}
@@ -422,7 +555,7 @@ func executeHoverAnimated(page *rod.Page, action Action, currentCursor CursorPos
 
 	// Capture hover state
 	for i := 0; i < opts.FPS/4; i++ {
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			continue
 		}
@@ -449,7 +582,7 @@ func captureWaitFrames(page *rod.Page, cursor CursorPosition, waitMs int, frameI
 	}
 
 	for i := 0; i < numFrames; i++ {
-		frame, err := captureFrame(page)
+		frame, err := captureFrame(page, frameInterval)
 		if err != nil {
 			continue
 		}
@@ -460,6 +593,50 @@ func captureWaitFrames(page *rod.Page, cursor CursorPosition, waitMs int, frameI
 	return frames
 }
 
+// captureFrames captures a fixed number of frames with the cursor held still
+func captureFrames(page *rod.Page, cursor CursorPosition, numFrames int, frameInterval time.Duration) []FrameData {
+	var frames []FrameData
+	for i := 0; i < numFrames; i++ {
+		frame, err := captureFrame(page, frameInterval)
+		if err != nil {
+			continue
+		}
+		frames = append(frames, FrameData{Image: frame, Cursor: cursor})
+		time.Sleep(frameInterval)
+	}
+	return frames
+}
+
+// animateCursorMove moves the mouse from the current cursor to (x, y) over
+// ~0.5 seconds, capturing a frame at each step
+func animateCursorMove(page *rod.Page, from CursorPosition, x, y int, state CursorState, opts Options, frameInterval time.Duration) []FrameData {
+	var frames []FrameData
+
+	movementFrames := opts.FPS / 2
+	if movementFrames < 5 {
+		movementFrames = 5
+	}
+
+	for i := 0; i <= movementFrames; i++ {
+		t := easeInOutQuad(float64(i) / float64(movementFrames))
+
+		interpX := int(float64(from.X) + t*(float64(x)-float64(from.X)))
+		interpY := int(float64(from.Y) + t*(float64(y)-float64(from.Y)))
+
+		page.Mouse.MustMoveTo(float64(interpX), float64(interpY))
+
+		frame, err := captureFrame(page, frameInterval)
+		if err != nil {
+			continue
+		}
+		frames = append(frames, FrameData{Image: frame, Cursor: CursorPosition{X: interpX, Y: interpY, State: state}})
+
+		time.Sleep(frameInterval / 2)
+	}
+
+	return frames
+}
+
 // easeInOutQuad provides smooth acceleration/deceleration
 func easeInOutQuad(t float64) float64 {
 	if t < 0.5 {
@@ -468,6 +645,42 @@ func easeInOutQuad(t float64) float64 {
 	return 1 - (-2*t+2)*(-2*t+2)/2
 }
 
+// findTarget resolves the element an action acts on, through its locator
+// strategies when it has them and otherwise by its selector
+func findTarget(page *rod.Page, action Action, opts Options) (*rod.Element, error) {
+	if action.Locator == nil {
+		return findElement(page, action.Selector)
+	}
+	el, strategy, err := action.Locator.Find(page, action.Selector)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Verbose && strategy != "css" {
+		fmt.Printf(" (found by %s)", strategy)
+	}
+	return el, nil
+}
+
+// findElement resolves an element of the page map (see crawler.FindElement)
+func findElement(page *rod.Page, selector string) (*rod.Element, error) {
+	return crawler.FindElement(page, selector)
+}
+
+// initialCursor returns the cursor a recording starts with, at the center of
+// the viewport
+func initialCursor(browser *crawler.Browser) CursorPosition {
+	w, h := browser.Viewport()
+	return CursorPosition{X: w / 2, Y: h / 2, State: CursorDefault}
+}
+
+// pressElement clicks an element, or taps it when emulating a touch screen
+func pressElement(el *rod.Element, opts Options) error {
+	if opts.Touch {
+		return el.Tap()
+	}
+	return el.Click(proto.InputMouseButtonLeft, 1)
+}
+
 func getElementCenter(el *rod.Element) (int, int, error) {
 	box, err := el.Shape()
 	if err != nil {
@@ -485,7 +698,12 @@ func getElementCenter(el *rod.Element) (int, int, error) {
 	return x, y, nil
 }
 
-func captureFrame(page *rod.Page) (image.Image, error) {
+// captureFrame takes a screenshot as the next frame. In deterministic mode
+// the page clock first moves on by one frame interval, so the page's own
+// animations play at the speed of the GIF.
+func captureFrame(page *rod.Page, frameInterval time.Duration) (image.Image, error) {
+	_ = crawler.AdvanceClock(page, frameInterval)
+
 	quality := 90
 	data, err := page.Screenshot(false, &proto.PageCaptureScreenshot{
 		Format:  proto.PageCaptureScreenshotFormatPng,
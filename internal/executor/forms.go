@@ -0,0 +1,251 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// selectMenuID is the id of the stand-in dropdown drawn while selecting
+const selectMenuID = "__demogif_select_menu"
+
+// showSelectMenuJS draws a dropdown under a select element, since headless
+// Chrome never renders the native popup into screenshots. It returns the
+// index of the option matching the value (by value, then by visible label),
+// or -1 if nothing matches.
+const showSelectMenuJS = `(id, wanted) => {
+	const options = Array.from(this.options);
+	let index = options.findIndex(o => o.value === wanted);
+	if (index === -1) index = options.findIndex(o => o.textContent.trim() === wanted);
+	if (index === -1) {
+		const lower = wanted.trim().toLowerCase();
+		index = options.findIndex(o => o.textContent.trim().toLowerCase() === lower);
+	}
+	if (index === -1) return -1;
+
+	const rect = this.getBoundingClientRect();
+	const style = getComputedStyle(this);
+	const menu = document.createElement('div');
+	menu.id = id;
+	Object.assign(menu.style, {
+		position: 'fixed',
+		left: rect.left + 'px',
+		top: (rect.bottom + 2) + 'px',
+		minWidth: rect.width + 'px',
+		maxHeight: '240px',
+		overflow: 'hidden',
+		background: '#fff',
+		color: '#111',
+		border: '1px solid #c4c4c4',
+		borderRadius: '4px',
+		boxShadow: '0 4px 12px rgba(0, 0, 0, 0.15)',
+		font: style.font,
+		zIndex: '2147483647',
+		pointerEvents: 'none'
+	});
+
+	// Show a window of options around the current and chosen ones
+	const start = Math.max(0, Math.min(this.selectedIndex, index) - 2);
+	options.slice(start, start + 10).forEach((o, i) => {
+		const row = document.createElement('div');
+		row.textContent = o.textContent.trim() || ' ';
+		row.dataset.index = String(start + i);
+		Object.assign(row.style, { padding: '4px 10px', whiteSpace: 'nowrap' });
+		if (start + i === this.selectedIndex) {
+			row.style.background = '#e8e8e8';
+		}
+		menu.appendChild(row);
+	});
+	document.body.appendChild(menu);
+	return index;
+}`
+
+// highlightSelectOptionJS marks the chosen option in the stand-in dropdown
+const highlightSelectOptionJS = `(id, index) => {
+	const menu = document.getElementById(id);
+	if (!menu) return;
+	menu.querySelectorAll('div').forEach(row => {
+		const chosen = row.dataset.index === String(index);
+		row.style.background = chosen ? '#1a73e8' : 'transparent';
+		row.style.color = chosen ? '#fff' : '#111';
+	});
+}`
+
+// applySelectOptionJS closes the stand-in dropdown and selects the option
+// the way a user would, firing input and change events
+const applySelectOptionJS = `(id, index) => {
+	const menu = document.getElementById(id);
+	if (menu) menu.remove();
+	this.selectedIndex = index;
+	this.dispatchEvent(new Event('input', { bubbles: true }));
+	this.dispatchEvent(new Event('change', { bubbles: true }));
+}`
+
+// executeSelectAnimated chooses an option of a native select element,
+// drawing a dropdown so the choice is visible in the recording
+func executeSelectAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
+	el, err := findTarget(page, action, opts)
+	if err != nil {
+		return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Selector, err)
+	}
+
+	x, y, err := getElementCenter(el)
+	if err != nil {
+		return nil, currentCursor, err
+	}
+
+	frames := animateCursorMove(page, currentCursor, x, y, CursorPointer, opts, frameInterval)
+	cursor := CursorPosition{X: x, Y: y, State: CursorPointer}
+
+	res, err := el.Eval(showSelectMenuJS, selectMenuID, action.Value)
+	if err != nil {
+		return nil, currentCursor, err
+	}
+	index := res.Value.Int()
+	if index < 0 {
+		return nil, currentCursor, fmt.Errorf("no option matching %q in %s", *action.Value, action.Selector)
+	}
+
+	// Click opens the menu
+	clicked := cursor
+	clicked.Click = true
+	frames = append(frames, captureFrames(page, clicked, opts.FPS/5, frameInterval)...)
+	frames = append(frames, captureFrames(page, cursor, opts.FPS/5, frameInterval)...)
+
+	// Highlight the choice, then apply it
+	if _, err := el.Eval(highlightSelectOptionJS, selectMenuID, index); err != nil {
+		return nil, currentCursor, err
+	}
+	frames = append(frames, captureFrames(page, cursor, opts.FPS/3, frameInterval)...)
+
+	if _, err := el.Eval(applySelectOptionJS, selectMenuID, index); err != nil {
+		return nil, currentCursor, err
+	}
+	frames = append(frames, captureFrames(page, cursor, opts.FPS/4, frameInterval)...)
+
+	return frames, cursor, nil
+}
+
+// executeCheckAnimated sets a checkbox or radio button to the state the
+// action asks for, clicking it only if it isn't in that state already
+func executeCheckAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
+	el, err := findTarget(page, action, opts)
+	if err != nil {
+		return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Selector, err)
+	}
+
+	want := action.Type == "check"
+	checked, err := el.Property("checked")
+	if err != nil {
+		return nil, currentCursor, err
+	}
+
+	// Styled inputs are often invisible; fall back to leaving the cursor put
+	cursor := currentCursor
+	var frames []FrameData
+	if x, y, err := getElementCenter(el); err == nil {
+		frames = animateCursorMove(page, currentCursor, x, y, CursorPointer, opts, frameInterval)
+		cursor = CursorPosition{X: x, Y: y, State: CursorPointer}
+	}
+
+	if checked.Bool() != want {
+		// Styled inputs are often covered by their label or hidden, which
+		// rod's click would wait on forever; click those from script instead
+		_ = el.ScrollIntoView()
+		_, err := el.Interactable()
+		if err == nil {
+			err = pressElement(el, opts)
+		}
+		if err != nil {
+			if _, err := el.Eval(`() => this.click()`); err != nil {
+				return nil, currentCursor, err
+			}
+		}
+
+		clicked := cursor
+		clicked.Click = true
+		frames = append(frames, captureFrames(page, clicked, opts.FPS/3, frameInterval)...)
+
+		checked, err = el.Property("checked")
+		if err != nil {
+			return nil, currentCursor, err
+		}
+		if checked.Bool() != want {
+			return nil, currentCursor, fmt.Errorf("%s did not become %sed", action.Selector, action.Type)
+		}
+	}
+
+	frames = append(frames, captureFrames(page, cursor, opts.FPS/4, frameInterval)...)
+	return frames, cursor, nil
+}
+
+// dropZoneJS finds where a user would click to pick files for a file input:
+// the input itself, its label, or the nearest visible ancestor
+const dropZoneJS = `() => {
+	let zone = this.offsetParent ? this : (this.labels && this.labels[0]);
+	if (!zone || !zone.offsetParent) {
+		zone = this.parentElement;
+		while (zone && !zone.offsetParent) zone = zone.parentElement;
+	}
+	return zone || null;
+}`
+
+// executeUploadAnimated sets local files on a file input, moving the cursor
+// to its visible drop zone first
+func executeUploadAnimated(page *rod.Page, action Action, currentCursor CursorPosition, opts Options, frameInterval time.Duration) ([]FrameData, CursorPosition, error) {
+	if len(action.Files) == 0 {
+		return nil, currentCursor, fmt.Errorf("upload needs at least one file")
+	}
+
+	el, err := findTarget(page, action, opts)
+	if err != nil {
+		return nil, currentCursor, fmt.Errorf("element not found: %s: %w", action.Selector, err)
+	}
+
+	paths, err := fixturePaths(action.Files, opts)
+	if err != nil {
+		return nil, currentCursor, err
+	}
+
+	cursor := currentCursor
+	var frames []FrameData
+	// The zone's shape rather than its client rect, which would be relative
+	// to the frame when the input is inside one
+	if zone, err := el.ElementByJS(rod.Eval(dropZoneJS)); err == nil {
+		if x, y, err := getElementCenter(zone); err == nil {
+			frames = animateCursorMove(page, currentCursor, x, y, CursorPointer, opts, frameInterval)
+			cursor = CursorPosition{X: x, Y: y, State: CursorPointer}
+		}
+	}
+
+	if err := el.SetFiles(paths); err != nil {
+		return nil, currentCursor, fmt.Errorf("set files failed: %w", err)
+	}
+
+	clicked := cursor
+	clicked.Click = true
+	frames = append(frames, captureFrames(page, clicked, opts.FPS/3, frameInterval)...)
+	frames = append(frames, captureFrames(page, cursor, opts.FPS/3, frameInterval)...)
+
+	return frames, cursor, nil
+}
+
+// fixturePaths resolves upload file paths against the fixture directory and
+// checks that the files exist
+func fixturePaths(files []string, opts Options) ([]string, error) {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		path := file
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(opts.FixtureDir, path)
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("upload file: %w", err)
+		}
+		paths[i] = path
+	}
+	return paths, nil
+}
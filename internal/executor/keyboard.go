@@ -0,0 +1,139 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+)
+
+// namedKeys maps the key names accepted in a press action to rod keys
+var namedKeys = map[string]input.Key{
+	"enter":      input.Enter,
+	"return":     input.Enter,
+	"escape":     input.Escape,
+	"esc":        input.Escape,
+	"tab":        input.Tab,
+	"space":      input.Space,
+	"backspace":  input.Backspace,
+	"delete":     input.Delete,
+	"home":       input.Home,
+	"end":        input.End,
+	"pageup":     input.PageUp,
+	"pagedown":   input.PageDown,
+	"arrowup":    input.ArrowUp,
+	"arrowdown":  input.ArrowDown,
+	"arrowleft":  input.ArrowLeft,
+	"arrowright": input.ArrowRight,
+	"up":         input.ArrowUp,
+	"down":       input.ArrowDown,
+	"left":       input.ArrowLeft,
+	"right":      input.ArrowRight,
+	"f1":         input.F1,
+	"f2":         input.F2,
+	"f3":         input.F3,
+	"f4":         input.F4,
+	"f5":         input.F5,
+	"f6":         input.F6,
+	"f7":         input.F7,
+	"f8":         input.F8,
+	"f9":         input.F9,
+	"f10":        input.F10,
+	"f11":        input.F11,
+	"f12":        input.F12,
+}
+
+// modifierKeys maps modifier names to rod keys and the label shown in captions
+var modifierKeys = map[string]struct {
+	key   input.Key
+	label string
+}{
+	"ctrl":    {input.ControlLeft, "Ctrl"},
+	"control": {input.ControlLeft, "Ctrl"},
+	"shift":   {input.ShiftLeft, "Shift"},
+	"alt":     {input.AltLeft, "Alt"},
+	"option":  {input.AltLeft, "Alt"},
+	"meta":    {input.MetaLeft, "Cmd"},
+	"cmd":     {input.MetaLeft, "Cmd"},
+	"command": {input.MetaLeft, "Cmd"},
+	"⌘":       {input.MetaLeft, "Cmd"},
+}
+
+// chord is a parsed key combination such as "Ctrl+Shift+P"
+type chord struct {
+	modifiers []input.Key
+	key       input.Key
+	label     string // Human readable form, used for captions and logs
+}
+
+// parseChord parses a key or key combination. Keys are joined with "+",
+// modifiers come first and the last part is the key to press, e.g.
+// "Enter", "Escape", "Shift+Tab", "Meta+K". "Mod" means Cmd on macOS and
+// Ctrl elsewhere, matching how web apps bind cross-platform shortcuts.
+func parseChord(s string) (chord, error) {
+	parts := strings.Split(strings.TrimSpace(s), "+")
+	// A trailing "+" means the plus key itself, e.g. "Ctrl++"
+	if n := len(parts); n > 1 && parts[n-1] == "" && parts[n-2] == "" {
+		parts = append(parts[:len(parts)-2], "+")
+	}
+
+	var c chord
+	var labels []string
+	for i, part := range parts {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			return chord{}, fmt.Errorf("invalid key chord: %q", s)
+		}
+
+		if i < len(parts)-1 {
+			if name == "mod" {
+				name = "ctrl"
+				if input.IsMac {
+					name = "meta"
+				}
+			}
+			mod, ok := modifierKeys[name]
+			if !ok {
+				return chord{}, fmt.Errorf("unknown modifier %q in %q", part, s)
+			}
+			c.modifiers = append(c.modifiers, mod.key)
+			labels = append(labels, mod.label)
+			continue
+		}
+
+		key, label, err := parseKey(strings.TrimSpace(part))
+		if err != nil {
+			return chord{}, fmt.Errorf("%w in %q", err, s)
+		}
+		c.key = key
+		labels = append(labels, label)
+	}
+
+	c.label = strings.Join(labels, "+")
+	return c, nil
+}
+
+// parseKey resolves a single (non-modifier) key name
+func parseKey(name string) (input.Key, string, error) {
+	if key, ok := namedKeys[strings.ToLower(name)]; ok {
+		return key, key.Info().Code, nil
+	}
+
+	runes := []rune(name)
+	if len(runes) == 1 && runes[0] > ' ' && runes[0] <= '~' {
+		// Chords use the unshifted key, like a physical keyboard would
+		r := runes[0]
+		if r >= 'A' && r <= 'Z' {
+			r += 'a' - 'A'
+		}
+		return input.Key(r), strings.ToUpper(string(r)), nil
+	}
+
+	return 0, "", fmt.Errorf("unknown key %q", name)
+}
+
+// pressChord holds the modifiers, types the key and releases everything
+func pressChord(page *rod.Page, c chord) error {
+	return page.KeyActions().Press(c.modifiers...).Type(c.key).Do()
+}
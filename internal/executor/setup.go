@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/v0xg/demogif/internal/crawler"
+)
+
+// setupPollInterval is how often setup waits check their condition
+const setupPollInterval = 100 * time.Millisecond
+
+// SetupResult holds the outcome of a batch of setup actions
+type SetupResult struct {
+	HitCheckpoint   bool
+	CheckpointIndex int // Index of the checkpoint action that was hit (-1 if none)
+}
+
+// ExecuteSetup runs actions before recording starts, such as logging in.
+// Nothing is animated or captured, and the first failing action stops the
+// batch since the demo can't start from a broken setup. Like ExecuteBatch it
+// stops after the first checkpoint.
+func ExecuteSetup(browser *crawler.Browser, actions []Action, opts Options) (*SetupResult, error) {
+	page := browser.Page()
+	result := &SetupResult{CheckpointIndex: -1}
+
+	for i, action := range actions {
+		if opts.Verbose {
+			fmt.Printf("  [setup %d/%d] %s %s", i+1, len(actions), action.Type, action.Selector)
+		}
+
+		var requests *requestTracker
+		if action.WaitFor != nil && action.WaitFor.NetworkIdle && action.Type != "waitFor" {
+			requests = trackRequests(page)
+		}
+
+		if err := executeSetupAction(page, action, opts); err != nil {
+			requests.stop()
+			if opts.Verbose {
+				fmt.Printf(" ✗ (%v)\n", err)
+			}
+			return result, fmt.Errorf("setup action %d (%s %s): %w", i+1, action.Type, action.Selector, err)
+		}
+
+		if action.WaitFor != nil && action.Type != "waitFor" {
+			err := pollCondition(page, *action.WaitFor, requests, setupPollInterval, nil)
+			requests.stop()
+			if err != nil {
+				if opts.Verbose {
+					fmt.Printf(" ✗ (%v)\n", err)
+				}
+				return result, fmt.Errorf("setup action %d (%s %s): %w", i+1, action.Type, action.Selector, err)
+			}
+		}
+		if action.Duration > 0 {
+			d := time.Duration(action.Duration) * time.Millisecond
+			time.Sleep(d)
+			_ = crawler.AdvanceClock(page, d)
+		}
+
+		if opts.Verbose {
+			fmt.Println(" ✓")
+		}
+
+		if action.Checkpoint {
+			result.HitCheckpoint = true
+			result.CheckpointIndex = i
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// executeSetupAction performs one action directly, without cursor movement
+// or frame capture
+func executeSetupAction(page *rod.Page, action Action, opts Options) error {
+	if len(action.Frame) > 0 {
+		framePage, err := crawler.EnterFrame(page, action.Frame)
+		if err != nil {
+			return err
+		}
+		page = framePage
+	}
+
+	switch action.Type {
+	case "click", "hover", "type", "select", "check", "uncheck", "upload":
+		el, err := findTarget(page, action, opts)
+		if err != nil {
+			return fmt.Errorf("element not found: %s: %w", action.Selector, err)
+		}
+		return setupElementAction(page, el, action, opts)
+	case "press":
+		c, err := parseChord(action.Key)
+		if err != nil {
+			return err
+		}
+		if action.Selector != "" {
+			el, err := findTarget(page, action, opts)
+			if err != nil {
+				return fmt.Errorf("element not found: %s: %w", action.Selector, err)
+			}
+			if err := el.Focus(); err != nil {
+				return err
+			}
+		}
+		return pressChord(page, c)
+	case "scroll":
+		return page.Mouse.Scroll(float64(action.X), float64(action.Y), 1)
+	case "wait":
+		return nil // The duration is waited for after every action
+	case "assert":
+		if action.Selector == "" && action.Text == "" && action.URL == "" {
+			return fmt.Errorf("assert action needs a selector, text or url")
+		}
+		if err := pollCondition(page, assertCondition(action), nil, setupPollInterval, nil); err != nil {
+			return fmt.Errorf("assertion failed: %w", err)
+		}
+		return nil
+	case "waitFor":
+		if action.WaitFor == nil {
+			return fmt.Errorf("waitFor action needs a waitFor condition")
+		}
+		return pollCondition(page, *action.WaitFor, nil, setupPollInterval, nil)
+	case "navigate":
+		if err := page.Navigate(action.URL); err != nil {
+			return err
+		}
+		return page.WaitLoad()
+	default:
+		return fmt.Errorf("%s actions aren't supported in setup", action.Type)
+	}
+}
+
+// setupElementAction performs an action on a resolved element
+func setupElementAction(page *rod.Page, el *rod.Element, action Action, opts Options) error {
+	switch action.Type {
+	case "click":
+		return pressElement(el, opts)
+	case "hover":
+		return el.Hover()
+	case "type":
+		if err := pressElement(el, opts); err != nil {
+			return err
+		}
+		if _, err := prepareTextTarget(page, el, action.Append); err != nil {
+			return err
+		}
+		return page.InsertText(action.Text)
+	case "select":
+		if action.Value == nil {
+			return fmt.Errorf("select action needs a value")
+		}
+		res, err := el.Eval(showSelectMenuJS, selectMenuID, action.Value)
+		if err != nil {
+			return err
+		}
+		index := res.Value.Int()
+		if index < 0 {
+			return fmt.Errorf("no option matching %q in %s", *action.Value, action.Selector)
+		}
+		_, err = el.Eval(applySelectOptionJS, selectMenuID, index)
+		return err
+	case "check", "uncheck":
+		checked, err := el.Property("checked")
+		if err != nil {
+			return err
+		}
+		if checked.Bool() == (action.Type == "check") {
+			return nil
+		}
+		_, err = el.Eval(`() => this.click()`)
+		return err
+	case "upload":
+		if len(action.Files) == 0 {
+			return fmt.Errorf("upload needs at least one file")
+		}
+		paths, err := fixturePaths(action.Files, opts)
+		if err != nil {
+			return err
+		}
+		return el.SetFiles(paths)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package executor
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+)
+
+// Typing modes for the type action
+const (
+	TypingSteady = "steady" // Constant speed with light jitter (default)
+	TypingHuman  = "human"  // Uneven cadence with occasional typos that get corrected
+	TypingPaste  = "paste"  // Insert the whole value at once with a paste animation
+)
+
+// defaultWPM is the typing speed used when an action doesn't set one. It is
+// fast on purpose, about two characters per frame at 20 fps, so long text
+// doesn't drag on; a lower wpm gives slower, realistic typing.
+const defaultWPM = 480
+
+// qwertyNeighbors maps a key to the keys around it, used to pick realistic typos
+var qwertyNeighbors = map[rune]string{
+	'q': "wa", 'w': "qes", 'e': "wrd", 'r': "etf", 't': "ryg", 'y': "tuh", 'u': "yij", 'i': "uok", 'o': "ipl", 'p': "ol",
+	'a': "qsz", 's': "awdx", 'd': "sefc", 'f': "drgv", 'g': "fthb", 'h': "gyjn", 'j': "hukm", 'k': "jil", 'l': "kop",
+	'z': "asx", 'x': "zsdc", 'c': "xdfv", 'v': "cfgb", 'b': "vghn", 'n': "bhjm", 'm': "njk",
+}
+
+// typingModel decides the cadence and mistakes of animated typing
+type typingModel struct {
+	charDelay time.Duration // Average delay between keystrokes
+	jitter    float64       // Random variation applied to each delay (0.3 = ±30%)
+	typoRate  float64       // Probability of mistyping a letter (human mode only)
+	rng       *rand.Rand    // Source of the jitter and typos
+}
+
+// newTypingModel builds the typing model for an action, drawing its
+// randomness from rng, or from an unseeded source when rng is nil
+func newTypingModel(action Action, rng *rand.Rand) typingModel {
+	if rng == nil {
+		rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	wpm := action.WPM
+	if wpm <= 0 {
+		wpm = defaultWPM
+	}
+
+	// A "word" is five characters by convention
+	m := typingModel{
+		charDelay: time.Minute / time.Duration(wpm*5),
+		jitter:    0.3,
+		rng:       rng,
+	}
+	if action.Typing == TypingHuman {
+		m.jitter = 0.6
+		m.typoRate = 0.04
+	}
+	return m
+}
+
+// delay returns the pause before typing r, given the previously typed rune
+func (m typingModel) delay(prev, r rune) time.Duration {
+	d := float64(m.charDelay) * (1 + m.jitter*(m.rng.Float64()*2-1))
+
+	// Humans hesitate a little between words and after punctuation
+	if m.typoRate > 0 && (prev == ' ' || strings.ContainsRune(".,;:!?", prev)) && m.rng.Float64() < 0.3 {
+		d *= 2.5
+	}
+	if d < 0 {
+		d = 0
+	}
+	return time.Duration(d)
+}
+
+// typo returns a wrong key to press instead of r, if a typo should happen now
+func (m typingModel) typo(r rune) (rune, bool) {
+	if m.typoRate == 0 || m.rng.Float64() >= m.typoRate {
+		return 0, false
+	}
+	neighbors, ok := qwertyNeighbors[unicode.ToLower(r)]
+	if !ok {
+		return 0, false
+	}
+	wrong := rune(neighbors[m.rng.IntN(len(neighbors))])
+	if unicode.IsUpper(r) {
+		wrong = unicode.ToUpper(wrong)
+	}
+	return wrong, true
+}
+
+// typeText types text into the focused element following the typing model,
+// capturing a frame whenever at least one frame interval of typing has
+// passed. Frames follow the model's time rather than the wall clock, so slow
+// screenshots don't change how many frames the text takes.
+func typeText(page *rod.Page, text string, model typingModel, cursor CursorPosition, frameInterval time.Duration) ([]FrameData, error) {
+	var frames []FrameData
+	var elapsed, lastFrame time.Duration // Typing time so far and at the last frame
+	start := time.Now()
+
+	capture := func(force bool) {
+		if !force && elapsed-lastFrame < frameInterval {
+			return
+		}
+		frame, err := captureFrame(page, frameInterval)
+		if err != nil {
+			return
+		}
+		frames = append(frames, FrameData{Image: frame, Cursor: cursor})
+		lastFrame = elapsed
+	}
+
+	// Let d of typing time pass, sleeping unless screenshots already took it
+	pace := func(d time.Duration) {
+		elapsed += d
+		if remaining := time.Until(start.Add(elapsed)); remaining > 0 {
+			time.Sleep(remaining)
+		}
+	}
+
+	runes := []rune(text)
+	var prev rune
+	for i, r := range runes {
+		pace(model.delay(prev, r))
+
+		if wrong, ok := model.typo(r); ok {
+			if err := typeRune(page, wrong); err != nil {
+				return frames, err
+			}
+			capture(false)
+
+			// Notice the mistake, then correct it
+			pace(model.charDelay * 4)
+			capture(false)
+			if err := page.Keyboard.Type(input.Backspace); err != nil {
+				return frames, err
+			}
+			capture(false)
+			pace(model.delay(wrong, r))
+		}
+
+		if err := typeRune(page, r); err != nil {
+			return frames, err
+		}
+		capture(i == len(runes)-1)
+		prev = r
+	}
+
+	return frames, nil
+}
+
+// typeRune sends a single character, using key events when the key exists
+// on a US keyboard and inserting the text directly otherwise
+func typeRune(page *rod.Page, r rune) error {
+	switch {
+	case r == '\n':
+		return page.Keyboard.Type(input.Enter)
+	case r >= ' ' && r <= '~':
+		return page.Keyboard.Type(input.Key(r))
+	default:
+		return page.InsertText(string(r))
+	}
+}
+
+// pasteText inserts text into the focused element in one go and briefly
+// highlights the element so the paste reads as an action in the GIF
+func pasteText(page *rod.Page, el *rod.Element, text string) error {
+	if err := page.InsertText(text); err != nil {
+		return err
+	}
+	_, err := el.Eval(`() => {
+		this.animate(
+			[{ backgroundColor: 'rgba(66, 133, 244, 0.25)' }, { backgroundColor: 'transparent' }],
+			{ duration: 500, easing: 'ease-out' }
+		);
+	}`)
+	return err
+}
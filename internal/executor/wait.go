@@ -0,0 +1,227 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/v0xg/demogif/internal/crawler"
+)
+
+// defaultWaitTimeout bounds a wait condition that doesn't set its own timeout
+const defaultWaitTimeout = 10 * time.Second
+
+// assertTimeout is how long an assertion may take to become true, so that
+// assertions right after an action don't fail on a render that's in flight
+const assertTimeout = 3000 // ms
+
+// assertCondition turns the fields of an assert action into a condition:
+// selector alone checks visibility, selector with value checks an input's
+// value, text checks visible text and url checks the current URL
+func assertCondition(action Action) WaitCondition {
+	return WaitCondition{
+		Selector: action.Selector,
+		Value:    action.Value,
+		Text:     action.Text,
+		URL:      action.URL,
+		Timeout:  assertTimeout,
+	}
+}
+
+// String describes the condition for logs and errors
+func (c WaitCondition) String() string {
+	var parts []string
+	if c.Selector != "" {
+		if c.Hidden {
+			parts = append(parts, c.Selector+" to disappear")
+		} else {
+			parts = append(parts, c.Selector+" to appear")
+		}
+	}
+	if c.Value != nil {
+		parts = append(parts, fmt.Sprintf("value %q", *c.Value))
+	}
+	if c.Text != "" {
+		parts = append(parts, fmt.Sprintf("text %q", c.Text))
+	}
+	if c.URL != "" {
+		parts = append(parts, fmt.Sprintf("URL matching %q", c.URL))
+	}
+	if c.NetworkIdle {
+		parts = append(parts, "network idle")
+	}
+	if len(parts) == 0 {
+		return "nothing"
+	}
+	return strings.Join(parts, " and ")
+}
+
+// elementStateJS defines elementMatches(el, hidden, value), which checks the
+// visibility and value parts of a wait condition against an element or null
+const elementStateJS = `
+	function elementMatches(el, hidden, value) {
+		const visible = !!el && el.getClientRects().length > 0 &&
+			getComputedStyle(el).visibility !== 'hidden';
+		if (visible === hidden) return false;
+		return value === null || (!!el && el.value === value);
+	}`
+
+// checkWaitConditionJS evaluates the DOM parts of a wait condition
+const checkWaitConditionJS = `(selector, hidden, value, text) => {` + crawler.DeepQueryJS + elementStateJS + `
+	if (selector && !elementMatches(deepQuery(selector), hidden, value)) return false;
+	if (text && !(document.body && document.body.innerText.includes(text))) return false;
+	return true;
+}`
+
+// checkElementJS evaluates the element parts of a wait condition against
+// an element resolved on the Go side, such as that of a role locator
+const checkElementJS = `function (hidden, value) {` + elementStateJS + `
+	return elementMatches(this, hidden, value);
+}`
+
+// met reports whether the DOM and URL parts of the condition hold
+func (c WaitCondition) met(page *rod.Page) (bool, error) {
+	selector := c.Selector
+	if strings.HasPrefix(selector, "role=") {
+		// The DOM can't resolve role locators, the accessibility tree can
+		el, err := crawler.QueryRole(page, selector)
+		if err != nil {
+			return false, err
+		}
+		ok := c.Hidden && c.Value == nil
+		if el != nil {
+			res, err := el.Eval(checkElementJS, c.Hidden, c.Value)
+			if err != nil {
+				return false, err
+			}
+			ok = res.Value.Bool()
+		}
+		if !ok {
+			return false, nil
+		}
+		selector = ""
+	}
+
+	if selector != "" || c.Text != "" {
+		res, err := page.Eval(checkWaitConditionJS, selector, c.Hidden, c.Value, c.Text)
+		if err != nil {
+			return false, err
+		}
+		if !res.Value.Bool() {
+			return false, nil
+		}
+	}
+
+	if c.URL != "" {
+		res, err := page.Eval(`() => window.location.href`)
+		if err != nil {
+			return false, err
+		}
+		url := res.Value.String()
+		if re, err := regexp.Compile(c.URL); err == nil {
+			if !re.MatchString(url) {
+				return false, nil
+			}
+		} else if !strings.Contains(url, c.URL) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// requestTracker follows the page's requests from when it's created, so a
+// network idle condition checked after an action counts the requests the
+// action started
+type requestTracker struct {
+	ctx    context.Context
+	wait   func()
+	cancel context.CancelFunc
+}
+
+// trackRequests starts tracking the page's requests
+func trackRequests(page *rod.Page) *requestTracker {
+	ctx, cancel := context.WithCancel(page.GetContext())
+	return &requestTracker{
+		ctx:    ctx,
+		wait:   page.Context(ctx).WaitRequestIdle(500*time.Millisecond, nil, nil, nil),
+		cancel: cancel,
+	}
+}
+
+// stop ends the tracking; a nil tracker is a no-op
+func (t *requestTracker) stop() {
+	if t != nil {
+		t.cancel()
+	}
+}
+
+// waitForCondition captures frames until the condition holds or it times out.
+// requests tracks network idle from before the action, or is nil.
+func waitForCondition(page *rod.Page, cond WaitCondition, requests *requestTracker, cursor CursorPosition, frameInterval time.Duration) ([]FrameData, error) {
+	var frames []FrameData
+	err := pollCondition(page, cond, requests, frameInterval, func() {
+		frame, err := captureFrame(page, frameInterval)
+		if err == nil {
+			frames = append(frames, FrameData{Image: frame, Cursor: cursor})
+		}
+	})
+	return frames, err
+}
+
+// pollCondition checks the condition every interval, calling tick between
+// checks, until it holds or it times out. Network idle is judged from
+// requests when given, otherwise from the requests seen from now on.
+func pollCondition(page *rod.Page, cond WaitCondition, requests *requestTracker, interval time.Duration, tick func()) error {
+	timeout := defaultWaitTimeout
+	if cond.Timeout > 0 {
+		timeout = time.Duration(cond.Timeout) * time.Millisecond
+	}
+	deadline := time.Now().Add(timeout)
+
+	// Network idle is tracked from events in the background
+	var idle chan struct{}
+	if cond.NetworkIdle {
+		if requests == nil {
+			requests = trackRequests(page)
+			defer requests.stop()
+		}
+		idle = make(chan struct{})
+		go func() {
+			requests.wait()
+			if requests.ctx.Err() == nil {
+				close(idle)
+			}
+		}()
+	}
+
+	for {
+		// Errors usually mean the page is mid-navigation; check again next time
+		ok, _ := cond.met(page)
+		if ok && idle != nil {
+			select {
+			case <-idle:
+			default:
+				ok = false
+			}
+		}
+		if ok {
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for %s", timeout, cond)
+		}
+
+		if tick != nil {
+			tick()
+		} else {
+			// Nothing is recorded, so let the page clock keep up
+			_ = crawler.AdvanceClock(page, interval)
+		}
+		time.Sleep(interval)
+	}
+}
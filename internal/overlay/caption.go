@@ -0,0 +1,136 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/v0xg/demogif/internal/executor"
+)
+
+// captionMargin is the distance between the caption and the bottom edge in
+// CSS pixels
+const captionMargin = 32
+
+// ApplyCaptions draws the keystroke captions of key presses on frames. It is
+// independent of the cursor overlay, so captions show with --no-cursor too.
+func ApplyCaptions(frames []image.Image, positions []executor.CursorPosition, opts Options) []image.Image {
+	if len(positions) == 0 {
+		return frames
+	}
+
+	result := make([]image.Image, len(frames))
+	interpolated := interpolatePositions(positions, len(frames))
+	for i, frame := range frames {
+		keys := interpolated[i].Keys
+		if keys == "" {
+			result[i] = frame
+			continue
+		}
+		bounds := frame.Bounds()
+		img := image.NewRGBA(bounds)
+		draw.Draw(img, bounds, frame, bounds.Min, draw.Src)
+		drawKeyCaption(img, keys, opts.Scale)
+		result[i] = img
+	}
+	return result
+}
+
+// drawKeyCaption draws a keystroke badge (e.g. "Cmd+K") centered near the
+// bottom of the frame, scaled up so it stays legible after GIF downscaling.
+// dpr is the device pixel ratio of the frame (0 means 1).
+func drawKeyCaption(img *image.RGBA, text string, dpr float64) {
+	if dpr <= 0 {
+		dpr = 1
+	}
+	bounds := img.Bounds()
+	cssScale := int(float64(bounds.Dx()) / dpr / 640)
+	if cssScale < 2 {
+		cssScale = 2
+	}
+	scale := int(math.Max(1, math.Round(float64(cssScale)*dpr)))
+	margin := int(math.Round(captionMargin * dpr))
+
+	mask := renderText(text)
+	textW := mask.Bounds().Dx() * scale
+	textH := mask.Bounds().Dy() * scale
+
+	padX, padY := 10*scale, 6*scale
+	badge := image.Rect(0, 0, textW+2*padX, textH+2*padY)
+	badge = badge.Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-badge.Dx())/2,
+		bounds.Max.Y-margin-badge.Dy(),
+	))
+
+	// Badge background
+	bg := image.NewUniform(color.RGBA{20, 20, 20, 200})
+	draw.DrawMask(img, badge, bg, image.Point{}, roundedRect{badge, 4 * scale}, badge.Min, draw.Over)
+
+	// Text, scaled with nearest neighbour to keep the bitmap font crisp
+	fg := color.RGBA{255, 255, 255, 255}
+	origin := badge.Min.Add(image.Pt(padX, padY))
+	mb := mask.Bounds()
+	for y := 0; y < textH; y++ {
+		for x := 0; x < textW; x++ {
+			if mask.AlphaAt(mb.Min.X+x/scale, mb.Min.Y+y/scale).A > 127 {
+				setPixelSafe(img, origin.X+x, origin.Y+y, fg)
+			}
+		}
+	}
+}
+
+// renderText rasterizes text with the built-in bitmap font into an alpha mask
+func renderText(text string) *image.Alpha {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil()
+	height := face.Metrics().Height.Ceil()
+
+	mask := image.NewAlpha(image.Rect(0, 0, width, height))
+	d := font.Drawer{
+		Dst:  mask,
+		Src:  image.Opaque,
+		Face: face,
+		Dot:  fixed.P(0, face.Metrics().Ascent.Ceil()),
+	}
+	d.DrawString(text)
+	return mask
+}
+
+// roundedRect is an alpha mask for a rectangle with rounded corners
+type roundedRect struct {
+	rect   image.Rectangle
+	radius int
+}
+
+func (r roundedRect) ColorModel() color.Model { return color.AlphaModel }
+
+func (r roundedRect) Bounds() image.Rectangle { return r.rect }
+
+func (r roundedRect) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(r.rect)) {
+		return color.Transparent
+	}
+
+	// Distance into the nearest corner square, if any
+	cx, cy := 0, 0
+	if x < r.rect.Min.X+r.radius {
+		cx = r.rect.Min.X + r.radius - x
+	} else if x >= r.rect.Max.X-r.radius {
+		cx = x - (r.rect.Max.X - r.radius - 1)
+	}
+	if y < r.rect.Min.Y+r.radius {
+		cy = r.rect.Min.Y + r.radius - y
+	} else if y >= r.rect.Max.Y-r.radius {
+		cy = y - (r.rect.Max.Y - r.radius - 1)
+	}
+
+	if cx*cx+cy*cy > r.radius*r.radius {
+		return color.Transparent
+	}
+	return color.Opaque
+}
@@ -12,8 +12,14 @@ import (
 // CursorSize is the size of the cursor sprite
 const CursorSize = 20
 
+// Options configures how the cursor is drawn
+type Options struct {
+	Scale float64 // Device pixel ratio of the frames; positions are in CSS pixels (0 means 1)
+	Touch bool    // Draw a touch indicator instead of the arrow cursor
+}
+
 // ApplyCursor draws cursor and click effects on frames
-func ApplyCursor(frames []image.Image, positions []executor.CursorPosition) ([]image.Image, error) {
+func ApplyCursor(frames []image.Image, positions []executor.CursorPosition, opts Options) ([]image.Image, error) {
 	if len(positions) == 0 {
 		return frames, nil
 	}
@@ -25,7 +31,7 @@ func ApplyCursor(frames []image.Image, positions []executor.CursorPosition) ([]i
 
 	for i, frame := range frames {
 		pos := interpolated[i]
-		result[i] = drawCursorOnFrame(frame, pos)
+		result[i] = drawCursorOnFrame(frame, pos, opts)
 	}
 
 	return result, nil
@@ -62,6 +68,7 @@ func interpolatePositions(positions []executor.CursorPosition, frameCount int) [
 				Y:     int(float64(currentPos.Y) + progress*(float64(nextPos.Y)-float64(currentPos.Y))),
 				State: currentPos.State,
 				Click: currentPos.Click,
+				Keys:  currentPos.Keys,
 			}
 		} else {
 			result[i] = currentPos
@@ -80,7 +87,7 @@ func easeInOut(t float64) float64 {
 }
 
 // drawCursorOnFrame creates a new image with cursor overlay
-func drawCursorOnFrame(frame image.Image, pos executor.CursorPosition) image.Image {
+func drawCursorOnFrame(frame image.Image, pos executor.CursorPosition, opts Options) image.Image {
 	bounds := frame.Bounds()
 	result := image.NewRGBA(bounds)
 
@@ -92,19 +99,75 @@ func drawCursorOnFrame(frame image.Image, pos executor.CursorPosition) image.Ima
 		return result
 	}
 
+	// Positions are in CSS pixels, frames in device pixels
+	scale := opts.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	x := int(math.Round(float64(pos.X) * scale))
+	y := int(math.Round(float64(pos.Y) * scale))
+	size := int(math.Max(1, math.Round(scale)))
+
+	if opts.Touch {
+		drawTouchIndicator(result, x, y, size, pos.Click || pos.State == executor.CursorGrabbing)
+		return result
+	}
+
 	// Draw click ripple effect if clicking
 	if pos.Click {
-		drawClickRipple(result, pos.X, pos.Y)
+		drawScaled(result, x, y, size, func(img *image.RGBA, x, y int) {
+			drawClickRipple(img, x, y)
+		})
 	}
 
 	// Draw cursor
-	drawCursor(result, pos.X, pos.Y, pos.State)
+	drawScaled(result, x, y, size, func(img *image.RGBA, x, y int) {
+		drawCursor(img, x, y, pos.State)
+	})
 
 	return result
 }
 
-// drawCursor draws a simple arrow cursor
+// spriteSize is the size of the canvas cursor shapes are drawn on before
+// scaling, centered on the cursor position
+const spriteSize = 48
+
+// drawScaled draws a shape at 1x and copies it onto img at (x, y), with
+// every pixel blown up to size×size so the cursor keeps its size relative
+// to the page on HiDPI frames
+func drawScaled(img *image.RGBA, x, y, size int, shape func(img *image.RGBA, x, y int)) {
+	if size <= 1 {
+		shape(img, x, y)
+		return
+	}
+
+	const origin = spriteSize / 2
+	sprite := image.NewRGBA(image.Rect(0, 0, spriteSize, spriteSize))
+	shape(sprite, origin, origin)
+
+	for sy := 0; sy < spriteSize; sy++ {
+		for sx := 0; sx < spriteSize; sx++ {
+			c := sprite.RGBAAt(sx, sy)
+			if c.A == 0 {
+				continue
+			}
+			left, top := x+(sx-origin)*size, y+(sy-origin)*size
+			for dy := 0; dy < size; dy++ {
+				for dx := 0; dx < size; dx++ {
+					setPixelSafe(img, left+dx, top+dy, c)
+				}
+			}
+		}
+	}
+}
+
+// drawCursor draws a simple arrow cursor, or a closed hand while dragging
 func drawCursor(img *image.RGBA, x, y int, state executor.CursorState) {
+	if state == executor.CursorGrabbing {
+		drawGrabbingCursor(img, x, y)
+		return
+	}
+
 	// Cursor outline (black)
 	cursorColor := color.RGBA{0, 0, 0, 255}
 	// Cursor fill (white)
@@ -139,6 +202,40 @@ func drawCursor(img *image.RGBA, x, y int, state executor.CursorState) {
 	}
 }
 
+// drawGrabbingCursor draws a closed hand centered on (x, y)
+func drawGrabbingCursor(img *image.RGBA, x, y int) {
+	outline := color.RGBA{0, 0, 0, 255}
+	fill := color.RGBA{255, 255, 255, 255}
+
+	// Fist: a rounded block with a thumb on the left
+	const w, h = 14, 12
+	left, top := x-w/2, y-h/2
+	for dy := 0; dy <= h; dy++ {
+		for dx := 0; dx <= w; dx++ {
+			corner := (dx == 0 || dx == w) && (dy == 0 || dy == h)
+			if corner {
+				continue
+			}
+			c := fill
+			if dx == 0 || dx == w || dy == 0 || dy == h {
+				c = outline
+			}
+			setPixelSafe(img, left+dx, top+dy, c)
+		}
+	}
+	// Knuckle lines
+	for _, dx := range []int{4, 7, 10} {
+		drawLine(img, left+dx, top+1, left+dx, top+4, outline)
+	}
+	// Thumb
+	drawLine(img, left-2, top+4, left-2, top+8, outline)
+	drawLine(img, left-2, top+4, left, top+4, outline)
+	drawLine(img, left-2, top+8, left, top+9, outline)
+	setPixelSafe(img, left-1, top+5, fill)
+	setPixelSafe(img, left-1, top+6, fill)
+	setPixelSafe(img, left-1, top+7, fill)
+}
+
 // isInsideCursor checks if a point is inside the cursor shape
 func isInsideCursor(dx, dy int) bool {
 	// Simple triangular cursor approximation
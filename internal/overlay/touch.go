@@ -0,0 +1,75 @@
+package overlay
+
+import (
+	"image"
+	"image/color"
+)
+
+// Touch indicator radii at 1x, in pixels
+const (
+	touchRadius       = 11
+	touchPressRadius  = 14
+	touchRippleRadius = 22
+)
+
+// drawTouchIndicator draws a translucent fingertip at (x, y). While pressed
+// it grows, darkens and gets a ripple ring, like the "show taps" option of
+// mobile operating systems.
+func drawTouchIndicator(img *image.RGBA, x, y, scale int, pressed bool) {
+	radius := touchRadius * scale
+	fill := color.RGBA{255, 255, 255, 140}
+	if pressed {
+		radius = touchPressRadius * scale
+		fill = color.RGBA{200, 200, 200, 180}
+		drawRing(img, x, y, touchRippleRadius*scale, scale, color.RGBA{66, 133, 244, 150})
+	}
+
+	fillCircle(img, x, y, radius, fill)
+	drawRing(img, x, y, radius, scale, color.RGBA{60, 60, 60, 160})
+}
+
+// fillCircle blends a filled circle onto img
+func fillCircle(img *image.RGBA, cx, cy, radius int, c color.RGBA) {
+	r2 := radius * radius
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= r2 {
+				blendPixel(img, cx+dx, cy+dy, c)
+			}
+		}
+	}
+}
+
+// drawRing blends a circle outline of the given thickness onto img, with
+// its outer edge at radius
+func drawRing(img *image.RGBA, cx, cy, radius, thickness int, c color.RGBA) {
+	outer := radius * radius
+	inner := (radius - thickness) * (radius - thickness)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			d := dx*dx + dy*dy
+			if d <= outer && d > inner {
+				blendPixel(img, cx+dx, cy+dy, c)
+			}
+		}
+	}
+}
+
+// blendPixel draws c over the pixel at (x, y), treating c as a straight
+// (non-premultiplied) color with alpha
+func blendPixel(img *image.RGBA, x, y int, c color.RGBA) {
+	if !(image.Point{x, y}.In(img.Bounds())) {
+		return
+	}
+	dst := img.RGBAAt(x, y)
+	a := uint32(c.A)
+	mix := func(s, d uint8) uint8 {
+		return uint8((uint32(s)*a + uint32(d)*(255-a)) / 255)
+	}
+	img.SetRGBA(x, y, color.RGBA{
+		R: mix(c.R, dst.R),
+		G: mix(c.G, dst.G),
+		B: mix(c.B, dst.B),
+		A: 255,
+	})
+}